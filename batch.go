@@ -0,0 +1,148 @@
+package index
+
+import (
+	"context"
+	"slices"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   CONSTANTS and PSEUDO-CONSTANTS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+const (
+	insertOperation = 'I'
+	deleteOperation = 'D'
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type batchOperation struct {
+	operation  byte
+	keyInput   string
+	keyElement int
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Batch collects Insert and Delete operations so that Apply can perform them all in one go
+//
+type Batch struct {
+	operations []batchOperation
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func applyRecords(records []logRecord, indexStructure *Index) (results []bool, nodesVisited int) {
+	// makes a set of changes (keys already validated) to the locked index -- logging them all in one go first
+	results = make([]bool, len(records))
+	if indexStructure.writeAheadLog != nil {
+		var loggedRecords []logRecord
+		for _, record := range records {
+			if record.keyString != "" {
+				loggedRecords = append(loggedRecords, record)
+			}
+		}
+		if !logChanges(loggedRecords, indexStructure) {
+			return
+		}
+	}
+	for i, record := range records {
+		keyVisited := 0
+		switch {
+		case record.keyString == "": // nothing to look for
+		case record.operation == insertOperation:
+			results[i], keyVisited = insertKey(record.keyString, record.keyElement, indexStructure.indexRootPointer, 0,
+				indexStructure)
+		case record.operation == deleteOperation:
+			results[i], keyVisited = deleteKey(record.keyString, record.keyElement, indexStructure)
+		}
+		nodesVisited += keyVisited
+		if results[i] {
+			publishChange(record.operation, record.keyString, record.keyElement, indexStructure)
+		}
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Insert adds an Insert of the input string and "element" number to the batch
+//
+func (batch *Batch) Insert(keyInput string, keyElement int) {
+	batch.operations = append(batch.operations, batchOperation{insertOperation, keyInput, keyElement})
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Delete adds a Delete of the input string and "element" number to the batch
+//
+func (batch *Batch) Delete(keyInput string, keyElement int) {
+	batch.operations = append(batch.operations, batchOperation{deleteOperation, keyInput, keyElement})
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Len returns how many operations are in the batch
+//
+func (batch *Batch) Len() (operationCount int) {
+	operationCount = len(batch.operations)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Reset empties the batch so that it can be filled again
+//
+func (batch *Batch) Reset() {
+	batch.operations = batch.operations[:0]
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Apply performs every operation in the batch, in the order they were added, with the index locked just once
+//       so no other call sees part of a batch -- 'results' holds the 'success' of each operation in the same order
+//
+func Apply(indexStructure *Index, batch *Batch) (results []bool) {
+	timer := lockIndex(context.Background(), OperationApply, 0, indexStructure)
+	defer func() { unlockIndex(timer, slices.Contains(results, true), 0, indexStructure) }()
+	//
+	records := make([]logRecord, 0, len(batch.operations))
+	for _, operation := range batch.operations {
+		keyString, _ := validate(operation.keyInput, indexStructure.collation)
+		records = append(records, logRecord{operation.operation, keyString, operation.keyElement})
+	}
+	results, timer.nodesVisited = applyRecords(records, indexStructure)
+	return
+}
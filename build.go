@@ -0,0 +1,217 @@
+package index
+
+import (
+	"iter"
+	"sort"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type sortedEntry struct {
+	keyString  string
+	keyElement int
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func appendNode(indexType, keyCharacter byte, indexStructure *Index) (newIndexPointer int) {
+	// adds a node to the end of the index -- pointers are filled in once the nodes after it have been built
+	indexStructure.node = append(indexStructure.node, indexNode{
+		indexType:    indexType,
+		leftPointer:  nullIndexPointer,
+		keyCharacter: keyCharacter,
+		rightPointer: nullIndexPointer,
+	})
+	newIndexPointer = len(indexStructure.node) - 1
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func buildBranches(entries []sortedEntry, depth, nextBranchBasePointer int, indexStructure *Index) (branchPointer int) {
+	// builds the 'branches' for a set of sorted keys that all match up to 'depth' and are all longer than that
+	// the last 'terminal' node is threaded to 'nextBranchBasePointer'
+	if entries[0].keyString[depth] == entries[len(entries)-1].keyString[depth] { // just the one 'branch'
+		branchPointer = buildBranch(entries, depth, nextBranchBasePointer, indexStructure)
+		return
+	}
+	branchStarts := []int{0}
+	for i := 1; i < len(entries); i++ {
+		if entries[i].keyString[depth] != entries[i-1].keyString[depth] {
+			branchStarts = append(branchStarts, i)
+		}
+	}
+	branchStarts = append(branchStarts, len(entries))
+	branchPointer = buildDecisions(entries, branchStarts, depth, nextBranchBasePointer, indexStructure)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func buildDecisions(entries []sortedEntry, branchStarts []int, depth, nextBranchBasePointer int,
+	indexStructure *Index) (branchPointer int) {
+	// builds a balanced set of 'decisionNodes' over the branches -- each one halves the branches left to choose from
+	branchCount := len(branchStarts) - 1
+	if branchCount == 1 {
+		branchPointer = buildBranch(entries[branchStarts[0]:branchStarts[1]], depth, nextBranchBasePointer,
+			indexStructure)
+		return
+	}
+	middle := (branchCount + 1) / 2
+	branchPointer = appendNode(decisionNode, entries[branchStarts[middle]-1].keyString[depth], indexStructure)
+	leftPointer := buildDecisions(entries, branchStarts[:middle+1], depth, branchPointer, indexStructure)
+	rightPointer := buildDecisions(entries, branchStarts[middle:], depth, nextBranchBasePointer, indexStructure)
+	indexStructure.node[branchPointer].leftPointer = leftPointer // can't assign directly -- 'node' may have moved
+	indexStructure.node[branchPointer].rightPointer = rightPointer
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func buildBranch(entries []sortedEntry, depth, nextBranchBasePointer int, indexStructure *Index) (branchPointer int) {
+	// builds the node for the character at 'depth' (shared by all the keys) and everything that follows it
+	endingCount := 0 // keys that end at this character come first
+	for endingCount < len(entries) && len(entries[endingCount].keyString) == depth+1 {
+		endingCount++
+	}
+	var keyElements []int
+	if endingCount == 1 {
+		keyElements = []int{entries[0].keyElement}
+	} else if endingCount > 1 {
+		keyElements = make([]int, endingCount)
+		for i, entry := range entries[:endingCount] {
+			keyElements[i] = entry.keyElement
+		}
+		sort.Ints(keyElements)
+		uniqueCount := 0
+		for i, keyElement := range keyElements { // drop any repeats
+			if i == 0 || keyElement != keyElements[uniqueCount-1] {
+				keyElements[uniqueCount] = keyElement
+				uniqueCount++
+			}
+		}
+		keyElements = keyElements[:uniqueCount]
+	}
+	moreEntries := entries[endingCount:]
+	//
+	var indexType byte
+	switch {
+	case len(keyElements) == 0:
+		indexType = characterNode
+	case len(keyElements) == 1 && len(moreEntries) > 0:
+		indexType = indexKeyNode
+	case len(keyElements) == 1:
+		indexType = indexTerminalNode
+	case len(moreEntries) > 0:
+		indexType = duplicateKeyNode
+	default:
+		indexType = duplicateTerminalNode
+	}
+	branchPointer = appendNode(indexType, entries[0].keyString[depth], indexStructure)
+	indexStructure.keyCount += len(keyElements)
+	//
+	leftPointer := nullIndexPointer
+	switch {
+	case len(keyElements) == 1:
+		leftPointer = keyElements[0]
+	case len(keyElements) > 1 && indexStructure.postingLists:
+		leftPointer = newPostingList(keyElements, indexStructure)
+	case len(keyElements) > 1: // a 'duplicates' branch threaded back to this node
+		duplicateEntries := make([]sortedEntry, len(keyElements))
+		for i, keyElement := range keyElements {
+			duplicateEntries[i].keyString, _ = decimaliseNumber(keyElement)
+			duplicateEntries[i].keyElement = keyElement
+		}
+		sort.Slice(duplicateEntries, func(i, j int) bool {
+			return duplicateEntries[i].keyString < duplicateEntries[j].keyString
+		})
+		leftPointer = buildBranches(duplicateEntries, 0, branchPointer, indexStructure)
+		indexStructure.keyCount -= len(duplicateEntries) // the 'duplicates' branch doesn't hold any more keys
+	}
+	rightPointer := nextBranchBasePointer
+	if len(moreEntries) > 0 {
+		rightPointer = buildBranches(moreEntries, depth+1, nextBranchBasePointer, indexStructure)
+	}
+	indexStructure.node[branchPointer].leftPointer = leftPointer
+	indexStructure.node[branchPointer].rightPointer = rightPointer
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func buildIndex(entries []sortedEntry, indexStructure *Index) {
+	// replaces the content of an (unlocked) index with the sorted keys -- keys must already be validated
+	if indexStructure.nodeShared { // start a new node array rather than overwrite the shared one
+		indexStructure.node = nil
+		indexStructure.nodeShared = false
+	}
+	indexStructure.node = indexStructure.node[:0]
+	indexStructure.indexRootPointer = nullIndexPointer
+	indexStructure.deletedRootPointer = nullIndexPointer
+	indexStructure.keyCount = 0
+	indexStructure.postingList = nil
+	indexStructure.postingOwned = nil
+	indexStructure.deletedPostings = nil
+	if len(entries) > 0 {
+		indexStructure.indexRootPointer = buildBranches(entries, 0, nullIndexPointer, indexStructure)
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// BuildSorted returns a new index holding every key and "element" number supplied -- the keys should already be in
+//             order (if trimming or shortening a key upsets the order they are sorted first)
+//             the node array is built in one pass from the bottom up with no searching -- every 'branch' is laid out
+//             in the order it is scanned and each set of 'decisionNodes' is balanced
+//
+func BuildSorted(entries iter.Seq2[string, int]) (indexStructure *Index) {
+	indexStructure = new(Index)
+	Initialise(indexStructure)
+	//
+	var sortedEntries []sortedEntry
+	inOrder := true
+	for keyInput, keyElement := range entries {
+		keyString, keyLength := validate(keyInput, nil)
+		if keyLength == 0 { // nothing to put in
+			continue
+		}
+		if len(sortedEntries) > 0 && keyString < sortedEntries[len(sortedEntries)-1].keyString {
+			inOrder = false
+		}
+		sortedEntries = append(sortedEntries, sortedEntry{keyString: keyString, keyElement: keyElement})
+	}
+	if !inOrder {
+		sort.SliceStable(sortedEntries, func(i, j int) bool {
+			return sortedEntries[i].keyString < sortedEntries[j].keyString
+		})
+	}
+	indexStructure.node = make([]indexNode, 0, 3*len(sortedEntries)) // a guess -- saves growing it over and over
+	buildIndex(sortedEntries, indexStructure)
+	return
+}
@@ -0,0 +1,372 @@
+// Command indexctl builds and inspects saved indexes (as written by index.Save)
+//
+//   indexctl build [-tsv] [-header] <input> <index file>   reads "key,element" lines (CSV, or TSV with -tsv) from
+//                                                           the input ("-" for stdin) and saves them as an index
+//   indexctl stats  <index file>                            prints index.Statistics
+//   indexctl select <index file> <key>                      prints the elements of a key, one per line
+//   indexctl search <index file> <prefix>                   prints the elements of every key starting with prefix
+//   indexctl range  <index file> <from> <to>                prints the elements of every key from 'from' to 'to'
+//   indexctl dump   <index file>                            prints every key and its elements in key order
+//   indexctl verify <index file>                            checks the file and the structure built from it
+//
+// it exits 2 for a usage error and 1 for any other failure
+//
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/apwoodhouse/index"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//  CONSTANTS and PSEUDO-CONSTANTS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+const usageText = `usage:
+  indexctl build [-tsv] [-header] <input> <index file>
+  indexctl stats  <index file>
+  indexctl select <index file> <key>
+  indexctl search <index file> <prefix>
+  indexctl range  <index file> <from> <to>
+  indexctl dump   <index file>
+  indexctl verify <index file>
+`
+
+var errUsage = errors.New("usage") // the usage has already been printed
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type buildEntry struct {
+	keyString  string
+	keyElement int
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func usageError() error {
+	fmt.Fprint(os.Stderr, usageText)
+	return errUsage
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func loadIndex(fileName string) (indexStructure *index.Index, err error) {
+	indexFile, err := os.Open(fileName)
+	if err != nil {
+		return
+	}
+	defer indexFile.Close()
+	//
+	indexStructure = new(index.Index)
+	index.Initialise(indexStructure)
+	if err = index.Load(bufio.NewReader(indexFile), indexStructure); err != nil {
+		err = fmt.Errorf("%s: %w", fileName, err)
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func readEntries(reader io.Reader, tabSeparated, skipHeader bool) (entries []buildEntry, err error) {
+	// reads "key,element" records -- a key is trimmed (and shortened) as index.Insert would
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = 2
+	if tabSeparated {
+		csvReader.Comma = '\t'
+		csvReader.LazyQuotes = true // a TSV field is never quoted -- a quote is just a character
+	}
+	for {
+		record, readErr := csvReader.Read()
+		if readErr == io.EOF {
+			return
+		}
+		if readErr != nil {
+			err = readErr
+			return
+		}
+		if skipHeader {
+			skipHeader = false
+			continue
+		}
+		keyElement, convertErr := strconv.Atoi(strings.TrimSpace(record[1]))
+		if convertErr != nil {
+			line, _ := csvReader.FieldPos(1)
+			err = fmt.Errorf("line %d: element %q is not a whole number", line, record[1])
+			return
+		}
+		entries = append(entries, buildEntry{keyString: record[0], keyElement: keyElement})
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func buildCommand(arguments []string) (err error) {
+	flagSet := flag.NewFlagSet("build", flag.ContinueOnError)
+	tabSeparated := flagSet.Bool("tsv", false, "the input is tab-separated rather than comma-separated")
+	skipHeader := flagSet.Bool("header", false, "the first line of the input is a header and is skipped")
+	if err = flagSet.Parse(arguments); err != nil {
+		return errUsage
+	}
+	if flagSet.NArg() != 2 {
+		return usageError()
+	}
+	inputName, indexName := flagSet.Arg(0), flagSet.Arg(1)
+	//
+	input := os.Stdin
+	if inputName != "-" {
+		if input, err = os.Open(inputName); err != nil {
+			return
+		}
+		defer input.Close()
+	}
+	entries, err := readEntries(bufio.NewReader(input), *tabSeparated, *skipHeader)
+	if err != nil {
+		err = fmt.Errorf("%s: %w", inputName, err)
+		return
+	}
+	indexStructure := index.BuildSorted(func(yield func(string, int) bool) {
+		for _, entry := range entries {
+			if !yield(entry.keyString, entry.keyElement) {
+				return
+			}
+		}
+	})
+	//
+	indexFile, err := os.Create(indexName)
+	if err != nil {
+		return
+	}
+	fileWriter := bufio.NewWriter(indexFile)
+	if err = index.Save(fileWriter, indexStructure); err == nil {
+		err = fileWriter.Flush()
+	}
+	if closeErr := indexFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(indexName) // don't leave half an index behind
+		return
+	}
+	fmt.Printf("%d keys saved to %s\n", index.Count(indexStructure), indexName)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func writeHistogram(writer io.Writer, histogramName string, histogram []int) {
+	// one line for each value that occurs -- "key lengths  3  12" is twelve keys three characters long
+	for value, valueCount := range histogram {
+		if valueCount > 0 {
+			fmt.Fprintf(writer, "%s\t%d\t%d\n", histogramName, value, valueCount)
+			histogramName = ""
+		}
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func statsCommand(arguments []string) (err error) {
+	if len(arguments) != 1 {
+		return usageError()
+	}
+	indexStructure, err := loadIndex(arguments[0])
+	if err != nil {
+		return
+	}
+	result, _ := index.Statistics(indexStructure)
+	tabWriter := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tabWriter, "keys\t%d\t\n", index.Count(indexStructure))
+	fmt.Fprintf(tabWriter, "active nodes\t%d\t\n", result.Active)
+	fmt.Fprintf(tabWriter, "deleted nodes\t%d\t\n", result.Deleted)
+	fmt.Fprintf(tabWriter, "depth\t%d\t\n", result.Depth)
+	fmt.Fprintf(tabWriter, "decision nodes\t%d\t\n", result.DecisionNodeCount)
+	fmt.Fprintf(tabWriter, "character nodes\t%d\t\n", result.CharacterNodeCount)
+	fmt.Fprintf(tabWriter, "index key nodes\t%d\t\n", result.IndexKeyNodeCount)
+	fmt.Fprintf(tabWriter, "index terminal nodes\t%d\t\n", result.IndexTerminalNodeCount)
+	fmt.Fprintf(tabWriter, "duplicate key nodes\t%d\t\n", result.DuplicateKeyNodeCount)
+	fmt.Fprintf(tabWriter, "duplicate terminal nodes\t%d\t\n", result.DuplicateTerminalNodeCount)
+	fmt.Fprintf(tabWriter, "node bytes\t%d\t\n", result.NodeBytes)
+	fmt.Fprintf(tabWriter, "deleted ratio\t%.3f\t\n", result.DeletedRatio)
+	fmt.Fprintf(tabWriter, "average path length\t%.2f\t\n", result.AveragePathLength)
+	fmt.Fprintf(tabWriter, "maximum path length\t%d\t\n", result.MaximumPathLength)
+	fmt.Fprintf(tabWriter, "largest duplicate set\t%d\t%s\n", result.LargestDuplicateCount,
+		printableKey(string(result.LargestDuplicateKey)))
+	writeHistogram(tabWriter, "key lengths", result.KeyLengths)
+	writeHistogram(tabWriter, "branching factors", result.BranchingFactors)
+	err = tabWriter.Flush()
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func queryCommand(arguments []string, argumentCount int,
+	query func(arguments []string, indexStructure *index.Index) (bool, []int)) (err error) {
+	// handles select, search and range -- the index file and then the query's own arguments
+	if len(arguments) != 1+argumentCount {
+		return usageError()
+	}
+	indexStructure, err := loadIndex(arguments[0])
+	if err != nil {
+		return
+	}
+	_, results := query(arguments[1:], indexStructure)
+	standardOutput := bufio.NewWriter(os.Stdout)
+	for _, keyElement := range results {
+		fmt.Fprintln(standardOutput, keyElement)
+	}
+	err = standardOutput.Flush()
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func printableKey(keyString string) string {
+	// a stored key may be binary (or hold a tab) -- such keys are quoted so every line of a dump can be read back
+	if !utf8.ValidString(keyString) || strings.HasPrefix(keyString, `"`) {
+		return strconv.Quote(keyString)
+	}
+	for _, keyRune := range keyString {
+		if !unicode.IsPrint(keyRune) {
+			return strconv.Quote(keyString)
+		}
+	}
+	return keyString
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func dumpCommand(arguments []string) (err error) {
+	if len(arguments) != 1 {
+		return usageError()
+	}
+	indexStructure, err := loadIndex(arguments[0])
+	if err != nil {
+		return
+	}
+	standardOutput := bufio.NewWriter(os.Stdout)
+	for entry := range index.Entries("", indexStructure) {
+		elementStrings := make([]string, len(entry.Elements))
+		for i, keyElement := range entry.Elements {
+			elementStrings[i] = strconv.Itoa(keyElement)
+		}
+		fmt.Fprintf(standardOutput, "%s\t%s\n", printableKey(entry.Key), strings.Join(elementStrings, ","))
+	}
+	err = standardOutput.Flush()
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func verifyCommand(arguments []string) (err error) {
+	// loading checks the file itself (its checksum and key order) -- Verify then checks what was built from it
+	if len(arguments) != 1 {
+		return usageError()
+	}
+	indexStructure, err := loadIndex(arguments[0])
+	if err != nil {
+		return
+	}
+	if err = index.Verify(indexStructure); err != nil {
+		err = fmt.Errorf("%s: %w", arguments[0], err)
+		return
+	}
+	fmt.Printf("%s: ok -- %d keys\n", arguments[0], index.Count(indexStructure))
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func run(arguments []string) (err error) {
+	if len(arguments) == 0 {
+		return usageError()
+	}
+	switch arguments[0] {
+	case "build":
+		err = buildCommand(arguments[1:])
+	case "stats":
+		err = statsCommand(arguments[1:])
+	case "select":
+		err = queryCommand(arguments[1:], 1, func(arguments []string, indexStructure *index.Index) (bool, []int) {
+			return index.Select(arguments[0], indexStructure)
+		})
+	case "search":
+		err = queryCommand(arguments[1:], 1, func(arguments []string, indexStructure *index.Index) (bool, []int) {
+			return index.Search(arguments[0], indexStructure)
+		})
+	case "range":
+		err = queryCommand(arguments[1:], 2, func(arguments []string, indexStructure *index.Index) (bool, []int) {
+			return index.Range(arguments[0], arguments[1], indexStructure)
+		})
+	case "dump":
+		err = dumpCommand(arguments[1:])
+	case "verify":
+		err = verifyCommand(arguments[1:])
+	default:
+		err = usageError()
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func main() {
+	err := run(os.Args[1:])
+	switch {
+	case err == nil:
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	default:
+		fmt.Fprintln(os.Stderr, "indexctl:", err)
+		os.Exit(1)
+	}
+}
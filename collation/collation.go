@@ -0,0 +1,64 @@
+// Package collation provides index.Collation functions based on the Unicode Collation Algorithm
+//
+package collation
+
+import (
+	"sync"
+
+	"github.com/apwoodhouse/index"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func weightBoundary(sortKey []byte, maxLength int) (keyLength int) {
+	// finds the length of the longest run of whole primary weights in the sort key no longer than 'maxLength' -- a
+	// weight is two bytes, or three if the top bit of its first byte is set
+	for keyLength < len(sortKey) {
+		weightLength := 2
+		if sortKey[keyLength]&0x80 != 0 {
+			weightLength = 3
+		}
+		if keyLength+weightLength > min(maxLength, len(sortKey)) {
+			break
+		}
+		keyLength += weightLength
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Primary returns a collation that orders keys alphabetically for the supplied language
+//         only the primary (letter) level is used -- case and accents are ignored so "Zoë" and "zoe" are the same key
+//         primary sort keys keep prefixes so Search still works -- each letter takes two bytes of the key length
+//         (three for most Chinese, Japanese and Korean characters) so a key holds 16 letters at most (10 of those)
+//         a longer key is cut after the last whole letter -- keys that only differ after that are the same key
+//
+func Primary(languageTag language.Tag) index.Collation {
+	var collatorMutex sync.Mutex
+	collator := collate.New(languageTag, collate.IgnoreCase, collate.IgnoreDiacritics, collate.IgnoreWidth)
+	var keyBuffer collate.Buffer
+	//
+	return func(keyString string) string {
+		collatorMutex.Lock() // a collator is not safe to share between indexes without this
+		defer collatorMutex.Unlock()
+		//
+		keyBuffer.Reset()
+		sortKey := collator.KeyFromString(&keyBuffer, keyString)
+		return string(sortKey[:weightBoundary(sortKey, index.MaxKeyLength)])
+	}
+}
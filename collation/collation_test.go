@@ -0,0 +1,98 @@
+package collation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/apwoodhouse/index"
+	"golang.org/x/text/language"
+)
+
+func TestWeightBoundary(t *testing.T) {
+	tests := []struct {
+		name      string
+		sortKey   []byte
+		maxLength int
+		keyLength int
+	}{
+		{"empty", nil, 4, 0},
+		{"short", []byte{0x15, 0xef, 0x16, 0x05}, 32, 4},
+		{"two-byte weights cut between", []byte{0x15, 0xef, 0x16, 0x05, 0x16, 0x1d}, 4, 4},
+		{"two-byte weight cut through", []byte{0x15, 0xef, 0x16, 0x05, 0x16, 0x1d}, 5, 4},
+		{"three-byte weights cut between", []byte{0x81, 0x65, 0xe5, 0x81, 0x67, 0x2c}, 3, 3},
+		{"three-byte weight cut through", []byte{0x81, 0x65, 0xe5, 0x81, 0x67, 0x2c}, 5, 3},
+		{"mixed", []byte{0x15, 0xef, 0x81, 0x65, 0xe5, 0x16, 0x05}, 6, 5},
+		{"mixed cut through the first", []byte{0x81, 0x65, 0xe5, 0x15, 0xef}, 2, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if keyLength := weightBoundary(test.sortKey, test.maxLength); keyLength != test.keyLength {
+				t.Errorf("got %d, want %d", keyLength, test.keyLength)
+			}
+		})
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestPrimary(t *testing.T) {
+	collation := Primary(language.English)
+	latinKey := strings.Repeat("abcd", 4)    // 16 letters -- the most a key holds
+	cjkKey := strings.Repeat("日本語", 3) + "日" // 10 characters -- the most a key holds
+	tests := []struct {
+		name                string
+		firstKey, secondKey string
+		same                bool
+		firstLength         int // bytes stored for the first key
+	}{
+		{"case and accents ignored", "Zoë", "zoe", true, 6},
+		{"different letters", "abc", "abd", false, 6},
+		{"latin -- differs in the last letter kept", latinKey[:15] + "x", latinKey, false, 32},
+		{"latin -- differs after the last letter kept", latinKey + "x", latinKey + "y", true, 32},
+		{"cjk -- differs in the last character kept", cjkKey[:27] + "本", cjkKey, false, 30},
+		{"cjk -- differs after the last character kept", cjkKey + "本", cjkKey + "語", true, 30},
+		{"cjk -- cut on a weight boundary", strings.Repeat("日本語", 4), cjkKey, true, 30},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			firstStored, secondStored := collation(test.firstKey), collation(test.secondKey)
+			if (firstStored == secondStored) != test.same {
+				t.Errorf("stored as % x and % x -- same %v, want %v", firstStored, secondStored,
+					firstStored == secondStored, test.same)
+			}
+			if len(firstStored) != test.firstLength || len(firstStored) > index.MaxKeyLength {
+				t.Errorf("stored in %d bytes, want %d", len(firstStored), test.firstLength)
+			}
+		})
+	}
+	if shortKey, longKey := collation("ab"), collation("abc"); !strings.HasPrefix(longKey, shortKey) {
+		t.Errorf("% x is not a prefix of % x", shortKey, longKey)
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestPrimaryIndex(t *testing.T) {
+	var indexStructure index.Index
+	index.Initialise(&indexStructure)
+	if !index.SetCollation(Primary(language.English), &indexStructure) {
+		t.Fatal("SetCollation failed on an empty index")
+	}
+	index.Insert("Zoë", 1, &indexStructure)
+	index.Insert("zoe", 2, &indexStructure)
+	index.Insert("Zebra", 3, &indexStructure)
+	index.Insert("apple", 4, &indexStructure)
+	if success, results := index.Select("ZOE", &indexStructure); !success || len(results) != 2 {
+		t.Errorf("Select returned %v %v", success, results)
+	}
+	if success, results := index.Search("Z", &indexStructure); !success || len(results) != 3 {
+		t.Errorf("Search returned %v %v", success, results)
+	}
+	if _, results := index.Scan(&indexStructure); len(results) != 4 || results[0] != 4 || results[1] != 3 {
+		t.Errorf("Scan returned %v -- want apple then Zebra (before Zoë)", results)
+	}
+}
@@ -0,0 +1,278 @@
+package index
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type concurrentCopy struct {
+	indexStructure Index
+	readerCount    atomic.Int64
+	writerWaiting  atomic.Bool   // a writer is waiting for the last reader to finish
+	readersGone    chan struct{} // ... and the last reader lets it know here
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// ConcurrentIndex is an index whose readers never wait for a lock -- for services that read far more than they write
+//                 it holds two copies of the index -- readers use whichever copy is 'current' while a writer
+//                 changes the other one and then swaps the 'current' pointer over (atomically) -- the same change is
+//                 made to the old copy at the start of the next write, once the readers still using it have finished
+//                 copying just the nodes along the changed path (and swapping in a new root) isn't possible -- the
+//                 'thread' pointers of the nodes below lead back up to the old ones, so every node of the branch
+//                 would have to be copied -- hence two copies, changed in place one after the other
+//                 the price is twice the memory of an Index, every change made twice and a writer that waits
+//                 (parked, not spinning) for the last reader of the old copy -- so it only pays where readers on
+//                 many processors would otherwise queue behind one another and changes are rare -- when changes are
+//                 frequent, or there are few processors, an Index is quicker -- BenchmarkConcurrentIndex compares the
+//                 two (run it with -cpu set to the processors the service will have)
+//
+type ConcurrentIndex struct {
+	writeMutex    sync.Mutex
+	current       atomic.Pointer[concurrentCopy]
+	indexCopies   [2]concurrentCopy
+	pendingChange func(indexStructure *Index) bool // the last change -- not yet made to the copy that isn't 'current'
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func startReading(concurrentStructure *ConcurrentIndex) (indexCopy *concurrentCopy) {
+	// finds the 'current' copy and registers as one of its readers -- 'stopReading' must be called when done
+	for {
+		indexCopy = concurrentStructure.current.Load()
+		indexCopy.readerCount.Add(1)
+		if concurrentStructure.current.Load() == indexCopy { // still 'current' so the writer will wait for us
+			return
+		}
+		stopReading(indexCopy) // a writer swapped the copies over in between -- try again
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func stopReading(indexCopy *concurrentCopy) {
+	// the last reader out wakes a writer waiting for the copy -- the writer says it is waiting before it looks at the
+	// count, and the reader looks for it after changing the count, so one of them always sees the other
+	if indexCopy.readerCount.Add(-1) == 0 && indexCopy.writerWaiting.Load() {
+		select {
+		case indexCopy.readersGone <- struct{}{}:
+		default: // already woken
+		}
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func writeCopies(concurrentStructure *ConcurrentIndex, change func(indexStructure *Index) bool) (success bool) {
+	// makes a change to the copy that isn't 'current' (after bringing it up to date) and makes that copy 'current'
+	concurrentStructure.writeMutex.Lock()
+	defer concurrentStructure.writeMutex.Unlock()
+	//
+	newCopy := &concurrentStructure.indexCopies[0]
+	if newCopy == concurrentStructure.current.Load() {
+		newCopy = &concurrentStructure.indexCopies[1]
+	}
+	if concurrentStructure.pendingChange != nil { // catch up -- once the last of its readers has gone
+		newCopy.writerWaiting.Store(true)
+		for newCopy.readerCount.Load() > 0 {
+			<-newCopy.readersGone // may be left from an earlier wait -- so look at the count again
+		}
+		newCopy.writerWaiting.Store(false)
+		concurrentStructure.pendingChange(&newCopy.indexStructure) // same start so the same result
+		concurrentStructure.pendingChange = nil
+	}
+	success = change(&newCopy.indexStructure)
+	if !success { // nothing was changed -- the copies are the same
+		return
+	}
+	concurrentStructure.current.Store(newCopy)
+	concurrentStructure.pendingChange = change
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// NewConcurrentIndex returns an empty concurrent index ready for use
+//
+func NewConcurrentIndex() (concurrentStructure *ConcurrentIndex) {
+	concurrentStructure = new(ConcurrentIndex)
+	for i := range concurrentStructure.indexCopies {
+		Initialise(&concurrentStructure.indexCopies[i].indexStructure)
+		concurrentStructure.indexCopies[i].readersGone = make(chan struct{}, 1)
+	}
+	concurrentStructure.current.Store(&concurrentStructure.indexCopies[0])
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// SetCollation sets the conversion applied to every key before it is stored or looked for -- as for SetCollation
+//              it can only be set on an empty index -- 'success' is false otherwise
+//
+func (concurrentStructure *ConcurrentIndex) SetCollation(collation Collation) (success bool) {
+	success = writeCopies(concurrentStructure, func(indexStructure *Index) bool {
+		if indexStructure.indexRootPointer != nullIndexPointer { // keys stored without it
+			return false
+		}
+		indexStructure.collation = collation
+		return true
+	})
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Insert places the input string into the index along with the supplied "element" number -- as for Insert
+//
+func (concurrentStructure *ConcurrentIndex) Insert(keyInput string, keyElement int) (success bool) {
+	success = writeCopies(concurrentStructure, func(indexStructure *Index) bool {
+		keyString, keyLength := validate(keyInput, indexStructure.collation)
+		if keyLength == 0 { // nothing to look for
+			return false
+		}
+		inserted, _ := insertKey(keyString, keyElement, indexStructure.indexRootPointer, 0, indexStructure)
+		return inserted
+	})
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Delete removes a key and its associated "element" number from the index -- as for Delete
+//
+func (concurrentStructure *ConcurrentIndex) Delete(keyInput string, keyElement int) (success bool) {
+	success = writeCopies(concurrentStructure, func(indexStructure *Index) bool {
+		keyString, keyLength := validate(keyInput, indexStructure.collation)
+		if keyLength == 0 { // nothing to look for
+			return false
+		}
+		deleted, _ := deleteKey(keyString, keyElement, indexStructure)
+		return deleted
+	})
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Scan returns an array of zero or many Element numbers based on the total content of the index -- as for Scan
+//
+func (concurrentStructure *ConcurrentIndex) Scan() (success bool, results []int) {
+	indexCopy := startReading(concurrentStructure)
+	defer stopReading(indexCopy)
+	//
+	indexStructure := &indexCopy.indexStructure
+	results, _ = traverseAndCollect(indexStructure.indexRootPointer, nullIndexPointer, indexStructure)
+	success = len(results) > 0
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Search returns an array of zero or many Element numbers for every key starting with the input -- as for Search
+//
+func (concurrentStructure *ConcurrentIndex) Search(keyInput string) (success bool, results []int) {
+	indexCopy := startReading(concurrentStructure)
+	defer stopReading(indexCopy)
+	//
+	keyString, keyLength := validate(keyInput, indexCopy.indexStructure.collation)
+	if keyLength == 0 { // nothing to look for
+		return
+	}
+	results, _ = searchKey(keyString, &indexCopy.indexStructure)
+	success = len(results) > 0
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Select returns an array of zero or many Element numbers for the input key -- as for Select
+//
+func (concurrentStructure *ConcurrentIndex) Select(keyInput string) (success bool, results []int) {
+	indexCopy := startReading(concurrentStructure)
+	defer stopReading(indexCopy)
+	//
+	keyString, keyLength := validate(keyInput, indexCopy.indexStructure.collation)
+	if keyLength == 0 { // nothing to look for
+		return
+	}
+	keyPointer, _ := findKey(keyString, &indexCopy.indexStructure)
+	if keyPointer == nullIndexPointer { // key doesn't match
+		return
+	}
+	results, _ = collectElements(keyPointer, &indexCopy.indexStructure)
+	success = len(results) > 0
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Range returns an array of zero or many Element numbers for every key from the first input string up to and
+//       including the second, in key order -- as for Range
+//
+func (concurrentStructure *ConcurrentIndex) Range(fromInput, toInput string) (success bool, results []int) {
+	indexCopy := startReading(concurrentStructure)
+	defer stopReading(indexCopy)
+	//
+	fromString, _ := validate(fromInput, indexCopy.indexStructure.collation)
+	toString, _ := validate(toInput, indexCopy.indexStructure.collation)
+	if toString != "" && fromString > toString { // nothing can be in the range
+		return
+	}
+	results, _ = rangeKeys(fromString, toString, &indexCopy.indexStructure)
+	success = len(results) > 0
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Count returns a count of how many Element numbers (keys) including duplicates, are contained in the index
+//
+func (concurrentStructure *ConcurrentIndex) Count() (keyCount int) {
+	indexCopy := startReading(concurrentStructure)
+	defer stopReading(indexCopy)
+	//
+	keyCount = indexCopy.indexStructure.keyCount
+	return
+}
@@ -0,0 +1,160 @@
+package index
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestConcurrentIndex(t *testing.T) {
+	concurrentStructure := NewConcurrentIndex()
+	if !concurrentStructure.SetCollation(strings.ToLower) {
+		t.Fatal("SetCollation failed on an empty index")
+	}
+	for i, keyInput := range []string{"Apple", "apply", "BANANA", "cherry", "apple"} {
+		concurrentStructure.Insert(keyInput, i+1)
+	}
+	if concurrentStructure.SetCollation(nil) {
+		t.Fatal("SetCollation succeeded on an index holding keys")
+	}
+	tests := []struct {
+		name    string
+		call    func() (bool, []int)
+		results []int
+	}{
+		{"select", func() (bool, []int) { return concurrentStructure.Select("APPLE") }, []int{1, 5}},
+		{"search", func() (bool, []int) { return concurrentStructure.Search("App") }, []int{1, 5, 2}},
+		{"range", func() (bool, []int) { return concurrentStructure.Range("applz", "Cherry") }, []int{3, 4}},
+		{"open range", func() (bool, []int) { return concurrentStructure.Range("b", "") }, []int{3, 4}},
+		{"empty range", func() (bool, []int) { return concurrentStructure.Range("c", "b") }, nil},
+		{"missing", func() (bool, []int) { return concurrentStructure.Select("date") }, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			success, results := test.call()
+			if success != (len(test.results) > 0) || !slices.Equal(results, test.results) {
+				t.Errorf("got %v %v, want %v", success, results, test.results)
+			}
+		})
+	}
+	if !concurrentStructure.Delete("Banana", 3) || concurrentStructure.Count() != 4 {
+		t.Fatal("Delete failed")
+	}
+	// a change that fails still brings the other copy up to date -- so both now hold the same keys
+	if concurrentStructure.Insert("", 6) {
+		t.Fatal("Insert of an empty key succeeded")
+	}
+	for i := range concurrentStructure.indexCopies {
+		_, results := Scan(&concurrentStructure.indexCopies[i].indexStructure)
+		if !slices.Equal(results, []int{1, 5, 2, 4}) {
+			t.Errorf("copy %d holds %v", i, results)
+		}
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestConcurrentIndexReadersAndWriter(t *testing.T) {
+	// readers keep the old copy in use while the writer changes the index -- each write has to wait for them (parked)
+	// and every read must see a whole number of writes -- the count never goes down and each key it covers is there
+	concurrentStructure := NewConcurrentIndex()
+	const writeCount = 2000
+	var stopped atomic.Bool
+	var readers sync.WaitGroup
+	for range 4 {
+		readers.Go(func() {
+			lastCount := 0
+			for !stopped.Load() {
+				keyCount := concurrentStructure.Count()
+				if keyCount < lastCount {
+					t.Errorf("Count went from %d to %d", lastCount, keyCount)
+					return
+				}
+				if keyCount > 0 {
+					if success, _ := concurrentStructure.Select("key" + strconv.Itoa(keyCount-1)); !success {
+						t.Errorf("key%d is missing with a count of %d", keyCount-1, keyCount)
+						return
+					}
+				}
+				lastCount = keyCount
+			}
+		})
+	}
+	for i := range writeCount {
+		if !concurrentStructure.Insert("key"+strconv.Itoa(i), i) {
+			t.Fatalf("Insert of key%d failed", i)
+		}
+	}
+	stopped.Store(true)
+	readers.Wait()
+	concurrentStructure.Insert("last", writeCount) // brings the other copy up to date
+	for i := range concurrentStructure.indexCopies {
+		if keyCount := Count(&concurrentStructure.indexCopies[i].indexStructure); keyCount < writeCount {
+			t.Errorf("copy %d holds %d keys", i, keyCount)
+		}
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// BenchmarkConcurrentIndex compares an Index with a ConcurrentIndex on the same mix of Select and (one in 'writeEvery')
+// Delete and Insert calls made from every processor -- run it with -cpu 1,2,4 ... to see where each is quicker
+//
+func BenchmarkConcurrentIndex(b *testing.B) {
+	const keyCount = 10000
+	keyStrings := make([]string, keyCount)
+	for i := range keyStrings {
+		keyStrings[i] = "key" + strconv.Itoa(i*7919%keyCount)
+	}
+	for _, writeEvery := range []int{0, 100, 10} { // no changes, one call in a hundred, one in ten
+		var indexStructure Index
+		Initialise(&indexStructure)
+		concurrentStructure := NewConcurrentIndex()
+		for i, keyString := range keyStrings {
+			Insert(keyString, i, &indexStructure)
+			concurrentStructure.Insert(keyString, i)
+		}
+		calls := map[string]struct {
+			read  func(keyString string)
+			write func(keyString string, keyElement int)
+		}{
+			"Index": {
+				func(keyString string) { Select(keyString, &indexStructure) },
+				func(keyString string, keyElement int) {
+					Delete(keyString, keyElement, &indexStructure)
+					Insert(keyString, keyElement, &indexStructure)
+				},
+			},
+			"ConcurrentIndex": {
+				func(keyString string) { concurrentStructure.Select(keyString) },
+				func(keyString string, keyElement int) {
+					concurrentStructure.Delete(keyString, keyElement)
+					concurrentStructure.Insert(keyString, keyElement)
+				},
+			},
+		}
+		for _, name := range []string{"Index", "ConcurrentIndex"} {
+			call := calls[name]
+			b.Run(name+"/writeEvery="+strconv.Itoa(writeEvery), func(b *testing.B) {
+				var callCount atomic.Int64
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						i := int(callCount.Add(1))
+						if writeEvery > 0 && i%writeEvery == 0 {
+							call.write(keyStrings[i%keyCount], i%keyCount)
+						} else {
+							call.read(keyStrings[i%keyCount])
+						}
+					}
+				})
+			})
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package index
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type treeDumper struct {
+	indexStructure *Index
+	dumpWriter     *bufio.Writer
+	nodeReached    []bool // so a damaged index (a pointer loop) can't send the dump round for ever
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func characterText(keyCharacter byte) string {
+	// a key character as Go would quote it -- 'a', '\t', '\xff'
+	if keyCharacter < 0x80 {
+		return strconv.QuoteRune(rune(keyCharacter))
+	}
+	return `'\x` + strconv.FormatUint(uint64(keyCharacter), 16) + `'`
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func pointerText(indexPointer int) string {
+	if indexPointer == nullIndexPointer {
+		return "-"
+	}
+	return strconv.Itoa(indexPointer)
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func elementsText(keyElements []int) string {
+	elementStrings := make([]string, len(keyElements))
+	for i, keyElement := range keyElements {
+		elementStrings[i] = strconv.Itoa(keyElement)
+	}
+	return "[" + strings.Join(elementStrings, " ") + "]"
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func leftText(indexPointer int, indexStructure *Index) string {
+	// what a node's leftPointer holds -- an element, a posting list or a pointer (or nothing)
+	thisNode := indexStructure.node[indexPointer]
+	switch thisNode.indexType {
+	case indexKeyNode, indexTerminalNode:
+		return "element " + strconv.Itoa(thisNode.leftPointer)
+	case duplicateKeyNode, duplicateTerminalNode:
+		if indexStructure.postingLists {
+			postingPointer := thisNode.leftPointer
+			if postingPointer >= 0 && postingPointer < len(indexStructure.postingList) {
+				return "list " + strconv.Itoa(postingPointer) + " " +
+					elementsText(appendPostingElements(nil, indexStructure.postingList[postingPointer]))
+			}
+			return "list " + strconv.Itoa(postingPointer)
+		}
+	}
+	return "L " + pointerText(thisNode.leftPointer)
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func dotString(textLines ...string) string {
+	// a DOT "quoted string" of one or more lines -- only the quote and backslash need escaping
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	for i, textLine := range textLines {
+		textLines[i] = escaper.Replace(textLine)
+	}
+	return `"` + strings.Join(textLines, `\n`) + `"`
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func deletedNodes(indexStructure *Index) (isDeleted []bool, deletedOrder []int) {
+	// the nodes on the 'deleted' list -- stopping if the list loops or leaves the node array
+	isDeleted = make([]bool, len(indexStructure.node))
+	for x := indexStructure.deletedRootPointer; x >= 0 && x < len(isDeleted) && !isDeleted[x]; {
+		isDeleted[x] = true
+		deletedOrder = append(deletedOrder, x)
+		x = indexStructure.node[x].rightPointer
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func writeDOTEdge(dotWriter *bufio.Writer, fromPointer, toPointer int, attributes string,
+	indexStructure *Index) {
+	if toPointer == nullIndexPointer {
+		return
+	}
+	if toPointer < 0 || toPointer >= len(indexStructure.node) { // a broken pointer -- worth seeing
+		fmt.Fprintf(dotWriter, "  bad%d_%d [label=%s, shape=plaintext, fontcolor=red];\n", fromPointer, toPointer,
+			dotString("bad "+strconv.Itoa(toPointer)))
+		fmt.Fprintf(dotWriter, "  n%d -> bad%d_%d [%s];\n", fromPointer, fromPointer, toPointer, attributes)
+		return
+	}
+	fmt.Fprintf(dotWriter, "  n%d -> n%d [%s];\n", fromPointer, toPointer, attributes)
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func dumpLine(indentLevel int, lineText string, dumper *treeDumper) {
+	fmt.Fprintf(dumper.dumpWriter, "%s%s\n", strings.Repeat("  ", indentLevel), lineText)
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func reachDumpNode(indexPointer, indentLevel int, dumper *treeDumper) (reachable bool) {
+	// says so (in the dump) if the pointer leads nowhere or back to a node already dumped
+	switch {
+	case indexPointer < 0 || indexPointer >= len(dumper.indexStructure.node):
+		dumpLine(indentLevel, "(branch ends at pointer "+strconv.Itoa(indexPointer)+")", dumper)
+	case dumper.nodeReached[indexPointer]:
+		dumpLine(indentLevel, "(node "+strconv.Itoa(indexPointer)+" reached again)", dumper)
+	default:
+		dumper.nodeReached[indexPointer] = true
+		reachable = true
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func dumpBranches(indexPointer int, keyPrefix []byte, indentLevel int, dumper *treeDumper) {
+	// dumps each of the branches a set of 'decisionNodes' chooses between -- all at the same indent
+	if !reachDumpNode(indexPointer, indentLevel, dumper) {
+		return
+	}
+	thisNode := dumper.indexStructure.node[indexPointer]
+	if thisNode.indexType == decisionNode {
+		dumpBranches(thisNode.leftPointer, keyPrefix, indentLevel, dumper)
+		dumpBranches(thisNode.rightPointer, keyPrefix, indentLevel, dumper)
+		return
+	}
+	dumpBranch(indexPointer, keyPrefix, indentLevel, dumper)
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func dumpBranch(indexPointer int, keyPrefix []byte, indentLevel int, dumper *treeDumper) {
+	// dumps the characters of a branch as one line up to the next key (shown with its elements) or the next set of
+	// 'decisionNodes' -- whatever follows is indented under it
+	indexStructure := dumper.indexStructure
+	segmentStart := len(keyPrefix)
+	for {
+		thisNode := indexStructure.node[indexPointer]
+		keyPrefix = append(keyPrefix, thisNode.keyCharacter)
+		segmentText := strconv.Quote(string(keyPrefix[segmentStart:]))
+		switch thisNode.indexType {
+		case indexKeyNode, indexTerminalNode:
+			dumpLine(indentLevel, segmentText+" -> "+strconv.Quote(string(keyPrefix))+" "+
+				elementsText([]int{thisNode.leftPointer}), dumper)
+		case duplicateKeyNode, duplicateTerminalNode:
+			duplicatesText := " duplicates "
+			if indexStructure.postingLists {
+				duplicatesText = " list "
+			}
+			duplicateElements, _ := collectElements(indexPointer, indexStructure)
+			dumpLine(indentLevel, segmentText+" -> "+strconv.Quote(string(keyPrefix))+duplicatesText+
+				elementsText(duplicateElements), dumper)
+		}
+		switch thisNode.indexType {
+		case indexTerminalNode, duplicateTerminalNode: // end of the branch
+			return
+		case indexKeyNode, duplicateKeyNode: // longer keys follow
+			indentLevel++
+			segmentStart = len(keyPrefix)
+		}
+		if len(keyPrefix) >= maxKeyLength {
+			dumpLine(indentLevel, "(key too long -- the branch doesn't end)", dumper)
+			return
+		}
+		indexPointer = thisNode.rightPointer
+		if indexPointer >= 0 && indexPointer < len(indexStructure.node) &&
+			indexStructure.node[indexPointer].indexType == decisionNode { // the keys branch here
+			if segmentStart < len(keyPrefix) {
+				dumpLine(indentLevel, strconv.Quote(string(keyPrefix[segmentStart:])), dumper)
+				indentLevel++
+			}
+			dumpBranches(indexPointer, keyPrefix, indentLevel, dumper)
+			return
+		}
+		if !reachDumpNode(indexPointer, indentLevel, dumper) {
+			return
+		}
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// WriteDOT writes the whole node array as a Graphviz DOT graph -- each node shows its subscript, type letter, key
+//          character and what its pointers hold -- the next character and the sides of a 'decisionNode' are solid
+//          edges, a 'duplicates' branch is dashed and the thread from a 'terminal' node back up the index is dotted
+//          the 'deleted' list is drawn apart (as a chain) in a box of its own
+//          render it with (for example) "dot -Tsvg index.dot -o index.svg"
+//
+func WriteDOT(writer io.Writer, indexStructure *Index) (err error) {
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	dotWriter := bufio.NewWriter(writer)
+	isDeleted, deletedOrder := deletedNodes(indexStructure)
+	fmt.Fprintln(dotWriter, "digraph index {")
+	fmt.Fprintln(dotWriter, "  node [shape=box, fontname=monospace];")
+	fmt.Fprintln(dotWriter, "  root [shape=plaintext];")
+	if indexStructure.indexRootPointer != nullIndexPointer {
+		fmt.Fprintf(dotWriter, "  root -> n%d;\n", indexStructure.indexRootPointer)
+	}
+	for indexPointer, thisNode := range indexStructure.node {
+		if isDeleted[indexPointer] {
+			continue
+		}
+		fmt.Fprintf(dotWriter, "  n%d [label=%s];\n", indexPointer, dotString(
+			fmt.Sprintf("%d: %c %s", indexPointer, thisNode.indexType, characterText(thisNode.keyCharacter)),
+			leftText(indexPointer, indexStructure)+"  R "+pointerText(thisNode.rightPointer)))
+		switch thisNode.indexType {
+		case decisionNode:
+			writeDOTEdge(dotWriter, indexPointer, thisNode.leftPointer,
+				"label="+dotString("<= "+characterText(thisNode.keyCharacter)), indexStructure)
+			writeDOTEdge(dotWriter, indexPointer, thisNode.rightPointer,
+				"label="+dotString("> "+characterText(thisNode.keyCharacter)), indexStructure)
+		case characterNode, indexKeyNode:
+			writeDOTEdge(dotWriter, indexPointer, thisNode.rightPointer, "", indexStructure)
+		case duplicateKeyNode:
+			if !indexStructure.postingLists {
+				writeDOTEdge(dotWriter, indexPointer, thisNode.leftPointer, "style=dashed, label=duplicates",
+					indexStructure)
+			}
+			writeDOTEdge(dotWriter, indexPointer, thisNode.rightPointer, "", indexStructure)
+		case indexTerminalNode:
+			writeDOTEdge(dotWriter, indexPointer, thisNode.rightPointer, "style=dotted, constraint=false",
+				indexStructure)
+		case duplicateTerminalNode:
+			if !indexStructure.postingLists {
+				writeDOTEdge(dotWriter, indexPointer, thisNode.leftPointer, "style=dashed, label=duplicates",
+					indexStructure)
+			}
+			writeDOTEdge(dotWriter, indexPointer, thisNode.rightPointer, "style=dotted, constraint=false",
+				indexStructure)
+		}
+	}
+	if len(deletedOrder) > 0 {
+		fmt.Fprintln(dotWriter, "  subgraph cluster_deleted {")
+		fmt.Fprintln(dotWriter, "    label=deleted; style=dashed; node [color=gray, fontcolor=gray];")
+		for _, indexPointer := range deletedOrder {
+			fmt.Fprintf(dotWriter, "    n%d [label=%s];\n", indexPointer, dotString(strconv.Itoa(indexPointer)))
+		}
+		for i := 1; i < len(deletedOrder); i++ {
+			fmt.Fprintf(dotWriter, "    n%d -> n%d [color=gray];\n", deletedOrder[i-1], deletedOrder[i])
+		}
+		fmt.Fprintln(dotWriter, "  }")
+	}
+	fmt.Fprintln(dotWriter, "}")
+	err = dotWriter.Flush()
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Dump writes the keys of the index as an indented tree -- each line is the characters of a branch up to the next
+//      key or the next point where the keys branch (at 'decisionNodes') -- the branches that follow are indented
+//      under it -- each key is shown in full with its elements, and whether they are in a 'duplicates' branch or
+//      a posting list -- see DumpNodes for the node array itself
+//
+func Dump(writer io.Writer, indexStructure *Index) (err error) {
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	dumper := treeDumper{
+		indexStructure: indexStructure,
+		dumpWriter:     bufio.NewWriter(writer),
+		nodeReached:    make([]bool, len(indexStructure.node)),
+	}
+	if indexStructure.indexRootPointer == nullIndexPointer {
+		dumpLine(0, "(empty)", &dumper)
+	} else {
+		dumpBranches(indexStructure.indexRootPointer, nil, 0, &dumper)
+	}
+	err = dumper.dumpWriter.Flush()
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// DumpNodes writes the raw node array as a table -- the subscript, type letter, key character, left and right
+//           pointers of every node (and whether it is on the 'deleted' list) -- then any posting lists
+//
+func DumpNodes(writer io.Writer, indexStructure *Index) (err error) {
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	dumpWriter := bufio.NewWriter(writer)
+	isDeleted, _ := deletedNodes(indexStructure)
+	fmt.Fprintf(dumpWriter, "root %s  deleted %s  keys %d\n", pointerText(indexStructure.indexRootPointer),
+		pointerText(indexStructure.deletedRootPointer), indexStructure.keyCount)
+	fmt.Fprintf(dumpWriter, "%8s  %-4s  %-6s  %10s  %10s\n", "node", "type", "char", "left", "right")
+	for indexPointer, thisNode := range indexStructure.node {
+		fmt.Fprintf(dumpWriter, "%8d  %-4c  %-6s  %10s  %10s", indexPointer, thisNode.indexType,
+			characterText(thisNode.keyCharacter), pointerText(thisNode.leftPointer), pointerText(thisNode.rightPointer))
+		if isDeleted[indexPointer] {
+			fmt.Fprint(dumpWriter, "  deleted")
+		}
+		fmt.Fprintln(dumpWriter)
+	}
+	for postingPointer, blocks := range indexStructure.postingList {
+		fmt.Fprintf(dumpWriter, "list %d %s\n", postingPointer, elementsText(appendPostingElements(nil, blocks)))
+	}
+	if len(indexStructure.deletedPostings) > 0 {
+		fmt.Fprintf(dumpWriter, "deleted lists %s\n", elementsText(indexStructure.deletedPostings))
+	}
+	err = dumpWriter.Flush()
+	return
+}
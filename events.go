@@ -0,0 +1,156 @@
+package index
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//  CONSTANTS and PSEUDO-CONSTANTS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+const (
+	// EventInsert is the Op of an Event for a key and "element" number that has been inserted
+	EventInsert EventOp = insertOperation
+	// EventDelete is the Op of an Event for a key and "element" number that has been deleted
+	EventDelete EventOp = deleteOperation
+	//
+	subscriptionBufferLength = 1024 // events a subscriber can fall behind by before it is dropped
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// EventOp says what kind of change an Event is
+//
+type EventOp byte
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Event is one change to an index as sent to subscribers -- 'Key' is as stored (after any collation) and 'Seq'
+//       numbers every change made to the index, starting at 1, in the order they were made
+//
+type Event struct {
+	Op      EventOp
+	Key     string
+	Element int
+	Seq     uint64
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type changeObserver struct { // is told of every change as it is made (with the index locked) -- it can't be dropped
+	observeChange func(event Event) // an event with no 'Op' means the whole content has been replaced
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func publishChange(operation byte, keyString string, keyElement int, indexStructure *Index) {
+	// numbers a change that has just been made and sends it to every subscriber -- the index must be locked
+	// a subscriber whose buffer is full is dropped (its channel closed) rather than hold up the index
+	indexStructure.changeSequence++
+	if len(indexStructure.subscribers) == 0 && len(indexStructure.changeObservers) == 0 {
+		return
+	}
+	event := Event{Op: EventOp(operation), Key: keyString, Element: keyElement, Seq: indexStructure.changeSequence}
+	for _, observer := range indexStructure.changeObservers {
+		observer.observeChange(event)
+	}
+	keptSubscribers := indexStructure.subscribers[:0]
+	for _, eventChannel := range indexStructure.subscribers {
+		select {
+		case eventChannel <- event:
+			keptSubscribers = append(keptSubscribers, eventChannel)
+		default: // too far behind
+			close(eventChannel)
+		}
+	}
+	clear(indexStructure.subscribers[len(keptSubscribers):])
+	indexStructure.subscribers = keptSubscribers
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func contentReplaced(indexStructure *Index) {
+	// the whole content of the (locked) index has been replaced -- which can't be sent as changes so every
+	// subscriber's channel is closed (and observers told)
+	indexStructure.changeSequence++
+	for _, observer := range indexStructure.changeObservers {
+		observer.observeChange(Event{Seq: indexStructure.changeSequence})
+	}
+	for _, eventChannel := range indexStructure.subscribers {
+		close(eventChannel)
+	}
+	indexStructure.subscribers = nil
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func subscribe(indexStructure *Index) (eventChannel chan Event) {
+	// adds a subscriber to the (locked) index
+	eventChannel = make(chan Event, subscriptionBufferLength)
+	indexStructure.subscribers = append(indexStructure.subscribers, eventChannel)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Subscribe returns a channel that receives an Event for every change made to the index from now on -- in the order
+//           the changes were made and only once each has been made (a transaction's changes when it commits)
+//           the channel holds a limited number of events -- a subscriber that falls that far behind is dropped and
+//           its channel closed (so it knows it has missed changes) -- the channel is also closed by Unsubscribe
+//           or when the whole content is replaced (Load or OpenWAL)
+//
+func Subscribe(indexStructure *Index) (eventChannel <-chan Event) {
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	eventChannel = subscribe(indexStructure)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Unsubscribe stops the events going to a channel returned by Subscribe and closes it
+//             'success' is false if the channel isn't subscribed (or has already been dropped)
+//
+func Unsubscribe(eventChannel <-chan Event, indexStructure *Index) (success bool) {
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	for i, subscribedChannel := range indexStructure.subscribers {
+		if (<-chan Event)(subscribedChannel) == eventChannel {
+			close(subscribedChannel)
+			indexStructure.subscribers = append(indexStructure.subscribers[:i], indexStructure.subscribers[i+1:]...)
+			success = true
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,85 @@
+package index
+
+import (
+	"strconv"
+	"testing"
+)
+
+func drainEvents(eventChannel <-chan Event) (events []Event, closed bool) {
+	// takes whatever is waiting on the channel without blocking
+	for {
+		select {
+		case event, open := <-eventChannel:
+			if !open {
+				closed = true
+				return
+			}
+			events = append(events, event)
+		default:
+			return
+		}
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestSlowSubscriberDropped(t *testing.T) {
+	tests := []struct {
+		name        string
+		changeCount int
+		readCount   int  // the subscriber reads each event as it comes for this many changes -- then stops reading
+		dropped     bool // its channel is closed -- having been sent the events up to when it fell too far behind
+	}{
+		{"keeps up", 3 * subscriptionBufferLength, 3 * subscriptionBufferLength, false},
+		{"fills the buffer", subscriptionBufferLength, 0, false},
+		{"one change too many", subscriptionBufferLength + 1, 0, true},
+		{"falls behind later", 1000 + subscriptionBufferLength + 5, 1000, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var indexStructure Index
+			Initialise(&indexStructure)
+			slowChannel := Subscribe(&indexStructure)
+			fastChannel := Subscribe(&indexStructure)
+			var slowEvents, fastEvents []Event
+			for i := range test.changeCount {
+				Insert("key"+strconv.Itoa(i), i, &indexStructure)
+				events, fastClosed := drainEvents(fastChannel)
+				if fastClosed {
+					t.Fatal("a subscriber that keeps up was dropped")
+				}
+				fastEvents = append(fastEvents, events...)
+				if i < test.readCount {
+					events, _ = drainEvents(slowChannel)
+					slowEvents = append(slowEvents, events...)
+				}
+			}
+			events, closed := drainEvents(slowChannel)
+			slowEvents = append(slowEvents, events...)
+			if closed != test.dropped {
+				t.Fatalf("dropped %v, want %v", closed, test.dropped)
+			}
+			if len(fastEvents) != test.changeCount {
+				t.Errorf("the subscriber that keeps up got %d events, want %d", len(fastEvents), test.changeCount)
+			}
+			// the events sent are the first ones, in order, with none missing
+			for i, event := range slowEvents {
+				if event.Seq != uint64(i+1) || event.Element != i || event.Op != EventInsert {
+					t.Fatalf("event %d is %+v", i, event)
+				}
+			}
+			wantCount := test.changeCount
+			if test.dropped { // what it read and then a full buffer
+				wantCount = test.readCount + subscriptionBufferLength
+			}
+			if len(slowEvents) != wantCount {
+				t.Errorf("got %d events, want %d", len(slowEvents), wantCount)
+			}
+			if Unsubscribe(slowChannel, &indexStructure) == test.dropped {
+				t.Errorf("Unsubscribe of a subscriber dropped %v returned %v", test.dropped, !test.dropped)
+			}
+		})
+	}
+}
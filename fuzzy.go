@@ -0,0 +1,115 @@
+package index
+
+import (
+	"context"
+	"slices"
+	"sort"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// FuzzyEntry is one key (as stored) found by SearchFuzzy, its "element" numbers and how many edits it is from the
+//            search string
+//
+type FuzzyEntry struct {
+	Key      string
+	Elements []int
+	Distance int
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type editDistances struct {
+	queryString string
+	maxEdits    int
+	prefixRows  prefixMemo[[]int] // [i][j] -- the edits from i characters of a prefix to j characters of the query
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (distances *editDistances) nextRow(previousRow []int, lowCharacter, highCharacter byte) (row []int) {
+	// the row of edit distances after one more character -- any from 'lowCharacter' to 'highCharacter', taking
+	// whichever is closest to the query at each point (so for a range it is the least the row could be)
+	row = make([]int, len(previousRow))
+	row[0] = previousRow[0] + 1 // an insertion
+	for j := 1; j < len(row); j++ {
+		row[j] = min(previousRow[j]+1, row[j-1]+1) // an insertion or a deletion
+		queryCharacter := distances.queryString[j-1]
+		if queryCharacter >= lowCharacter && queryCharacter <= highCharacter {
+			row[j] = min(row[j], previousRow[j-1])
+		} else {
+			row[j] = min(row[j], previousRow[j-1]+1) // a substitution
+		}
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (distances *editDistances) keyFilter(keyPrefix []byte, lowCharacter, highCharacter byte) bool {
+	// a 'keyWalker' filter that skips the branches where every key is already too many edits from the query
+	nextRow := distances.nextRow(stateAfter(keyPrefix, &distances.prefixRows), lowCharacter, highCharacter)
+	return slices.Min(nextRow) <= distances.maxEdits
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// SearchFuzzy returns every key (as stored) no more than 'maxEdits' edits from the input search string -- the
+//             Levenshtein distance, counting each byte inserted, deleted or substituted as one edit -- with its
+//             Element numbers, the closest first (then in key order)
+//             the distances are worked out a row at a time alongside the walk through the index and a branch is left
+//             as soon as every key in it must be too far away
+//             'success' is true if at least one key is close enough
+//
+func SearchFuzzy(keyInput string, maxEdits int, indexStructure *Index) (success bool, entries []FuzzyEntry) {
+	timer := lockIndex(context.Background(), OperationFuzzy, len(keyInput), indexStructure)
+	defer func() { unlockIndex(timer, success, len(entries), indexStructure) }()
+	//
+	queryString, queryLength := validate(keyInput, indexStructure.collation)
+	if queryLength == 0 || maxEdits < 0 { // nothing to look for
+		return
+	}
+	//
+	distances := editDistances{queryString: queryString, maxEdits: maxEdits}
+	startRow := make([]int, queryLength+1)
+	for j := range startRow {
+		startRow[j] = j
+	}
+	distances.prefixRows = newPrefixMemo(startRow, func(previousRow []int, keyCharacter byte) []int {
+		return distances.nextRow(previousRow, keyCharacter, keyCharacter)
+	})
+	walker := keyWalker{indexStructure: indexStructure, keyFilter: distances.keyFilter}
+	walker.keyVisit = func(keyString []byte, keyPointer int) bool {
+		if distance := stateAfter(keyString, &distances.prefixRows)[queryLength]; distance <= maxEdits {
+			entries = append(entries, FuzzyEntry{string(keyString), walker.collectElements(keyPointer), distance})
+		}
+		return true
+	}
+	walkKeys(indexStructure.indexRootPointer, nil, 0, 255, &walker)
+	timer.nodesVisited = walker.nodesVisited
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Distance < entries[j].Distance })
+	success = len(entries) > 0
+	return
+}
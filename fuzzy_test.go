@@ -0,0 +1,64 @@
+package index
+
+import (
+	"cmp"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func editDistance(fromString, toString string) (distance int) {
+	// the Levenshtein distance in bytes worked out in full -- a row of the table at a time
+	previousRow := make([]int, len(toString)+1)
+	for j := range previousRow {
+		previousRow[j] = j
+	}
+	for i := range len(fromString) {
+		thisRow := make([]int, len(toString)+1)
+		thisRow[0] = i + 1
+		for j := range len(toString) {
+			substitution := previousRow[j]
+			if fromString[i] != toString[j] {
+				substitution++
+			}
+			thisRow[j+1] = min(previousRow[j+1]+1, thisRow[j]+1, substitution)
+		}
+		previousRow = thisRow
+	}
+	distance = previousRow[len(toString)]
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestSearchFuzzy(t *testing.T) {
+	tests := []struct {
+		keyInput string
+		maxEdits int
+	}{
+		{"abc", -1}, {"abc", 0}, {"abc", 1}, {"abc", 2}, {"abcd", 1}, {"abcd", 3},
+		{"a", 0}, {"a", 1}, {"a", 2}, {"ccc", 1}, {"cba", 2}, {"abcabc", 2}, {"abcabc", 3},
+		{"zz", 1}, {"zz", 2}, {"zzz", 3}, {"a*c", 1}, {"b", 4},
+	}
+	for _, layout := range duplicateLayouts {
+		indexStructure := newFilterIndex(t, layout.postingLists)
+		for _, test := range tests {
+			t.Run(layout.name+"/"+test.keyInput+"/"+strconv.Itoa(test.maxEdits), func(t *testing.T) {
+				var want []FuzzyEntry
+				for _, entry := range allEntries(indexStructure) {
+					if distance := editDistance(entry.Key, test.keyInput); distance <= test.maxEdits {
+						want = append(want, FuzzyEntry{entry.Key, entry.Elements, distance})
+					}
+				}
+				slices.SortStableFunc(want, func(a, b FuzzyEntry) int { return cmp.Compare(a.Distance, b.Distance) })
+				success, entries := SearchFuzzy(test.keyInput, test.maxEdits, indexStructure)
+				if success != (len(want) > 0) || !reflect.DeepEqual(entries, want) {
+					t.Errorf("got %v %v, want %v", success, entries, want)
+				}
+			})
+		}
+	}
+}
@@ -1,10 +1,18 @@
 package index
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"iter"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+	"unsafe"
 )
 
 //
@@ -16,8 +24,11 @@ import (
 //
 
 const (
-	maxKeyLength     = 32
-	nullIndexPointer = -1
+	maxKeyLength       = 32
+	nullIndexPointer   = -1
+	contextCheckNodes  = 1024 // how many nodes are visited between checks for a cancelled context
+	postingBlockLength = 256  // most elements held in one block of a posting list
+	entriesPerLock     = 256  // keys Entries finds each time it locks the index
 	//
 	decisionNode          = 'D'
 	characterNode         = 'X'
@@ -31,6 +42,10 @@ const (
 	null  = ' '
 )
 
+// MaxKeyLength is the most bytes of a key that are stored -- a longer key is cut down to it (see Collation)
+//
+const MaxKeyLength = maxKeyLength
+
 //
 /////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
 //
@@ -50,6 +65,41 @@ type indexNode struct {
 /////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
 //
 
+// KeyEntry is one key (as stored -- after any collation) and its "element" numbers -- as returned by ScanKeys
+//
+type KeyEntry struct {
+	Key      string
+	Elements []int
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type keyWalker struct {
+	indexStructure *Index
+	// could any key starting with the prefix followed by a character in the range be wanted? -- nil wants every key
+	keyFilter func(keyPrefix []byte, lowCharacter, highCharacter byte) bool
+	// called for each key in order -- returning false stops the walk
+	keyVisit func(keyString []byte, keyPointer int) bool
+	// how many nodes the walk (and the elements it collected) has looked at -- see SpanAttributes
+	nodesVisited int
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type prefixMemo[S any] struct { // what a 'keyFilter' has worked out for each character of the last prefix
+	keyPrefix    []byte // the prefix that 'prefixStates' were worked out for
+	prefixStates []S    // [i] -- after the first i characters of the prefix
+	nextState    func(previousState S, keyCharacter byte) S
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
 // Statistic contains the results of the Statistics scan
 //
 type Statistic struct {
@@ -62,6 +112,48 @@ type Statistic struct {
 	IndexTerminalNodeCount     int `json:"IndexTerminalNodes"`
 	DuplicateKeyNodeCount      int `json:"DuplicateKeyNodes"`
 	DuplicateTerminalNodeCount int `json:"DuplicateTerminalNodes"`
+	// memory
+	NodeBytes    int     `json:"NodeBytes"`    // held by the node array -- including capacity not yet used
+	DeletedRatio float64 `json:"DeletedRatio"` // the part of the node array that is on the 'deleted' list
+	// shape -- histograms are subscripted by the value counted
+	KeyLengths            []int   `json:"KeyLengths"`       // how many keys have each length
+	BranchingFactors      []int   `json:"BranchingFactors"` // how often each number of next characters occurs
+	LargestDuplicateCount int     `json:"LargestDuplicateCount"`
+	LargestDuplicateKey   []byte  `json:"LargestDuplicateKey"` // as stored, so base64 in JSON -- the first if a tie
+	AveragePathLength     float64 `json:"AveragePathLength"`   // nodes visited from the root to find a key
+	MaximumPathLength     int     `json:"MaximumPathLength"`
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Collation converts a key into the byte string that is actually stored -- byte order of the result is the key order
+//           the conversion should preserve prefixes (the result for "ab" a prefix of the result for "abc") or Search
+//           will not find keys by their leading characters
+//           a result longer than MaxKeyLength is cut to that many bytes -- a conversion whose result is made of
+//           units of more than one byte should cut it after the last whole unit itself (as collation.Primary does)
+//           or keys that differ only in the part of a unit that was cut off become the same key
+//
+type Collation func(keyString string) string
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// postingBlocks holds the elements of a 'duplicate' node in order -- as blocks of no more than 'postingBlockLength'
+// so that a change only moves the elements of one block
+//
+type postingBlocks [][]int
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// indexLock is the index's mutex -- with a way for a call to stop waiting for it when its context is cancelled
+//
+type indexLock struct {
+	sync.Mutex
 }
 
 //
@@ -74,8 +166,22 @@ type Index struct {
 	indexRootPointer   int
 	node               []indexNode
 	deletedRootPointer int
-	indexMutex         sync.Mutex
+	indexMutex         indexLock
 	keyCount           int
+	collation          Collation
+	postingLists       bool            // duplicates are held in 'postingList' rather than as 'duplicates' branches
+	postingList        []postingBlocks // elements of each 'duplicate' node -- the node's leftPointer is the subscript
+	postingOwned       []bool          // the 'postingList' entry is held by this index alone -- so can be changed
+	deletedPostings    []int           // 'postingList' entries that can be re-used
+	nodeShared         bool            // 'node' and 'postingList' are also held elsewhere -- copy them before a change
+	readOnly           bool            // a 'snapshot' -- nothing can be changed
+	writeAheadLog      *WAL            // every change is written here first -- see OpenWAL
+	subscribers        []chan Event
+	changeObservers    []*changeObserver
+	changeSequence     uint64 // how many changes have been made -- see Subscribe
+	// read before the index is locked -- see SetMetrics and SetTracer
+	metrics atomic.Pointer[Metrics]
+	tracer  atomic.Pointer[Tracer]
 }
 
 //
@@ -86,6 +192,41 @@ type Index struct {
 /////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
 //
 
+func (lock *indexLock) lockContext(ctx context.Context) (err error) {
+	// locks unless the context is cancelled first -- 'err' is then ctx.Err() and the lock isn't held
+	// a wait that can be cancelled is handed to a goroutine that gives the lock back if nobody is left to take it
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	if ctx.Done() == nil { // can't be cancelled
+		lock.Lock()
+		return
+	}
+	if lock.TryLock() {
+		return
+	}
+	lockTaken, waitAbandoned := make(chan struct{}), make(chan struct{})
+	go func() {
+		lock.Lock()
+		select {
+		case lockTaken <- struct{}{}:
+		case <-waitAbandoned:
+			lock.Unlock()
+		}
+	}()
+	select {
+	case <-lockTaken:
+	case <-ctx.Done():
+		close(waitAbandoned)
+		err = ctx.Err()
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
 func decimaliseNumber(keyElement int) (keyString string, keyLength int) {
 	keyString = strconv.Itoa(keyElement)
 	keyLength = len(keyString)
@@ -96,10 +237,169 @@ func decimaliseNumber(keyElement int) (keyString string, keyLength int) {
 /////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
 //
 
-func validate(keyInput string) (keyString string, keyLength int) {
+func copyOnWrite(indexStructure *Index) {
+	// takes private copies of the node array and posting lists if they are shared -- before anything is changed
+	// -- each posting list itself is only copied when it is changed (see 'ownPostingList')
+	if !indexStructure.nodeShared {
+		return
+	}
+	indexStructure.node = append([]indexNode(nil), indexStructure.node...)
+	indexStructure.postingList = append([]postingBlocks(nil), indexStructure.postingList...)
+	indexStructure.postingOwned = make([]bool, len(indexStructure.postingList))
+	indexStructure.deletedPostings = append([]int(nil), indexStructure.deletedPostings...)
+	indexStructure.nodeShared = false
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func postingBlocksOf(keyElements []int) (blocks postingBlocks) {
+	// copies sorted elements into full blocks
+	for len(keyElements) > 0 {
+		blockLength := min(len(keyElements), postingBlockLength)
+		blocks = append(blocks, append(make([]int, 0, postingBlockLength), keyElements[:blockLength]...))
+		keyElements = keyElements[blockLength:]
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func appendPostingElements(results []int, blocks postingBlocks) []int {
+	for _, block := range blocks {
+		results = append(results, block...)
+	}
+	return results
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func findPostingBlock(blocks postingBlocks, keyElement int) (blockNumber, i int, found bool) {
+	// finds the block an element is in (or would go in) and where in it
+	blockNumber = sort.Search(len(blocks), func(b int) bool { return blocks[b][len(blocks[b])-1] >= keyElement })
+	if blockNumber == len(blocks) { // after every element -- goes on the end of the last block
+		blockNumber--
+		i = len(blocks[blockNumber])
+		return
+	}
+	i = sort.SearchInts(blocks[blockNumber], keyElement)
+	found = blocks[blockNumber][i] == keyElement
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func newPostingList(keyElements []int, indexStructure *Index) (postingPointer int) {
+	// stores a sorted list of elements for a 'duplicate' node -- re-using a 'deleted' list if there is one
+	deletedCount := len(indexStructure.deletedPostings)
+	if deletedCount == 0 { // append to end of the lists
+		indexStructure.postingList = append(indexStructure.postingList, postingBlocksOf(keyElements))
+		indexStructure.postingOwned = append(indexStructure.postingOwned, true)
+		postingPointer = len(indexStructure.postingList) - 1
+	} else { // use up one of the 'deleted' lists
+		postingPointer = indexStructure.deletedPostings[deletedCount-1]
+		indexStructure.deletedPostings = indexStructure.deletedPostings[:deletedCount-1]
+		indexStructure.postingList[postingPointer] = postingBlocksOf(keyElements)
+		indexStructure.postingOwned[postingPointer] = true
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func ownPostingList(postingPointer int, indexStructure *Index) (blocks postingBlocks) {
+	// returns a posting list that can be changed in place -- copying it first if a snapshot (or transaction) may
+	// still hold it
+	blocks = indexStructure.postingList[postingPointer]
+	if indexStructure.postingOwned[postingPointer] {
+		return
+	}
+	blocks = append(make(postingBlocks, 0, len(blocks)), blocks...)
+	for b, block := range blocks {
+		blocks[b] = append(make([]int, 0, postingBlockLength), block...)
+	}
+	indexStructure.postingList[postingPointer] = blocks
+	indexStructure.postingOwned[postingPointer] = true
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func addToPostingList(postingPointer, keyElement int, indexStructure *Index) (success bool) {
+	// places an element in a sorted list -- splitting its block in two if it is then too long
+	blockNumber, i, found := findPostingBlock(indexStructure.postingList[postingPointer], keyElement)
+	if found { // already there
+		return
+	}
+	blocks := ownPostingList(postingPointer, indexStructure)
+	block := slices.Insert(blocks[blockNumber], i, keyElement)
+	if len(block) > postingBlockLength {
+		splitBlock := append(make([]int, 0, postingBlockLength), block[len(block)/2:]...)
+		blocks = slices.Insert(blocks, blockNumber+1, splitBlock)
+		block = block[:len(block)/2]
+	}
+	blocks[blockNumber] = block
+	indexStructure.postingList[postingPointer] = blocks
+	success = true
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func removeFromPostingList(postingPointer, keyElement int, indexStructure *Index) (success bool) {
+	// takes an element out of a sorted list -- joining its block to the one before if both are then half full or less
+	blockNumber, i, found := findPostingBlock(indexStructure.postingList[postingPointer], keyElement)
+	if !found {
+		return
+	}
+	blocks := ownPostingList(postingPointer, indexStructure)
+	block := slices.Delete(blocks[blockNumber], i, i+1)
+	switch {
+	case len(block) == 0:
+		blocks = slices.Delete(blocks, blockNumber, blockNumber+1)
+	case blockNumber > 0 && len(blocks[blockNumber-1])+len(block) <= postingBlockLength/2: // join to the one before
+		blocks[blockNumber-1] = append(blocks[blockNumber-1], block...)
+		blocks = slices.Delete(blocks, blockNumber, blockNumber+1)
+	default:
+		blocks[blockNumber] = block
+	}
+	indexStructure.postingList[postingPointer] = blocks
+	success = true
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func validate(keyInput string, collation Collation) (keyString string, keyLength int) {
 	keyInput = strings.TrimSpace(keyInput)
-	if len(keyInput) >= maxKeyLength {
-		keyString = keyInput[0:maxKeyLength]
+	if collation != nil && len(keyInput) > 0 { // binary -- the collation should have cut it on a boundary already
+		keyInput = collation(keyInput)
+		if len(keyInput) >= maxKeyLength {
+			keyString = keyInput[0:maxKeyLength]
+		} else {
+			keyString = keyInput
+		}
+	} else if len(keyInput) > maxKeyLength {
+		cut := maxKeyLength
+		for cut > 0 && !utf8.RuneStart(keyInput[cut]) { // don't split a multi-byte character
+			cut--
+		}
+		keyString = keyInput[0:cut]
 	} else {
 		keyString = keyInput
 	}
@@ -126,12 +426,30 @@ func pushStack(inStack []int, indexPointer, inDepth int) (outStack []int, outDep
 /////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
 //
 
-func traverseAndCollect(startPointer, endPointer int, indexStructure *Index) (results []int) {
-	// collects 'results' of each 'index' node between the start and end pointers
+func traverseAndCollect(startPointer, endPointer int, indexStructure *Index) (results []int, nodesVisited int) {
+	results, nodesVisited, _ = traverseAndCollectContext(context.Background(), startPointer, endPointer,
+		indexStructure)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func traverseAndCollectContext(ctx context.Context, startPointer, endPointer int,
+	indexStructure *Index) (results []int, nodesVisited int, err error) {
+	// collects 'results' of each 'index' node between the start and end pointers -- stopping if 'ctx' is cancelled
 	direction := left
 	indexPointer := startPointer
 	//
 	for indexPointer != endPointer && indexPointer != nullIndexPointer {
+		nodesVisited++
+		if nodesVisited%contextCheckNodes == 0 {
+			if err = ctx.Err(); err != nil {
+				results = nil
+				return
+			}
+		}
 		switch indexStructure.node[indexPointer].indexType {
 		case indexKeyNode:
 			results = append(results, indexStructure.node[indexPointer].leftPointer)
@@ -142,14 +460,23 @@ func traverseAndCollect(startPointer, endPointer int, indexStructure *Index) (re
 			indexPointer = indexStructure.node[indexPointer].rightPointer
 			direction = right
 		case duplicateKeyNode:
-			if direction == left {
+			if indexStructure.postingLists { // all the 'duplicates' are in the list
+				postingPointer := indexStructure.node[indexPointer].leftPointer
+				results = appendPostingElements(results, indexStructure.postingList[postingPointer])
+				indexPointer = indexStructure.node[indexPointer].rightPointer
+			} else if direction == left {
 				indexPointer = indexStructure.node[indexPointer].leftPointer
 			} else {
 				indexPointer = indexStructure.node[indexPointer].rightPointer
 			}
 			direction = left
 		case duplicateTerminalNode:
-			if direction == left {
+			if indexStructure.postingLists { // all the 'duplicates' are in the list
+				postingPointer := indexStructure.node[indexPointer].leftPointer
+				results = appendPostingElements(results, indexStructure.postingList[postingPointer])
+				indexPointer = indexStructure.node[indexPointer].rightPointer
+				direction = right
+			} else if direction == left {
 				indexPointer = indexStructure.node[indexPointer].leftPointer
 			} else {
 				indexPointer = indexStructure.node[indexPointer].rightPointer
@@ -173,56 +500,33 @@ func traverseAndCollect(startPointer, endPointer int, indexStructure *Index) (re
 /////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
 //
 
-func extend(keyString string, keyElement, nextBranchBasePointer int, indexStructure *Index) (extensionPointer int) {
-	// creates key nodes in reverse order -- points the leaf node at the next 'branch' sent in as a parameter
-	var newIndexNode indexNode
-	var newIndexPointer int
-	//
-	keyLength := len(keyString)
-	extensionPointer = nextBranchBasePointer
-	//
-	for i := keyLength - 1; i >= 0; i-- {
-		// create new index node
-		byteArray := []byte(keyString[i : i+1])
-		newIndexNode.keyCharacter = byteArray[0]
-		newIndexNode.rightPointer = extensionPointer
-		if i == keyLength-1 { // leaf node
-			newIndexNode.indexType = indexTerminalNode
-			newIndexNode.leftPointer = keyElement
-		} else { // character node
-			newIndexNode.indexType = characterNode
-			newIndexNode.leftPointer = nullIndexPointer
-		}
-		//
-		if indexStructure.deletedRootPointer == nullIndexPointer { // append to end of indexStructure
-			indexStructure.node = append(indexStructure.node, newIndexNode)
-			newIndexPointer = len(indexStructure.node) - 1
-		} else { // use up one of the 'deleted' nodes
-			newIndexPointer = indexStructure.deletedRootPointer
-			indexStructure.deletedRootPointer = indexStructure.node[newIndexPointer].rightPointer
-			indexStructure.node[newIndexPointer] = newIndexNode
-		}
-		extensionPointer = newIndexPointer
-	}
+func searchKey(keyString string, indexStructure *Index) (results []int, nodesVisited int) {
+	results, nodesVisited, _ = searchKeyContext(context.Background(), keyString, indexStructure)
 	return
 }
 
-//
-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
-//
-//   'USER' INTERFACE
 //
 /////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
 //
 
-// Initialise sets up an index structure -- must be executed before anything else is performed
-//
-func Initialise(indexStructure *Index) {
-	indexStructure.indexMutex.Lock()
-	defer indexStructure.indexMutex.Unlock()
-	//
-	indexStructure.indexRootPointer = nullIndexPointer
-	indexStructure.deletedRootPointer = nullIndexPointer
+func searchKeyContext(ctx context.Context, keyString string, indexStructure *Index) (results []int, nodesVisited int,
+	err error) {
+	// collects the elements of every key that starts with the (validated) search string -- unless 'ctx' is cancelled
+	prefixPointer, endPointer, nodesVisited := findPrefix(keyString, indexStructure)
+	if prefixPointer == nullIndexPointer { // key doesn't match
+		return
+	}
+	startPointer := prefixPointer
+	switch indexStructure.node[prefixPointer].indexType {
+	case duplicateKeyNode, duplicateTerminalNode:
+		if !indexStructure.postingLists { // otherwise the node itself holds the 'duplicates'
+			startPointer = indexStructure.node[prefixPointer].leftPointer // into the 'duplicates' branch
+		}
+	case characterNode:
+		startPointer = indexStructure.node[prefixPointer].rightPointer
+	}
+	results, collectVisited, err := traverseAndCollectContext(ctx, startPointer, endPointer, indexStructure)
+	nodesVisited += collectVisited
 	return
 }
 
@@ -230,99 +534,113 @@ func Initialise(indexStructure *Index) {
 /////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
 //
 
-// Scan returns an array of zero or many Element numbers based on the total content of the supplied index
-//      'success' is true if at least one result is found
-//
-func Scan(indexStructure *Index) (success bool, results []int) {
-	indexStructure.indexMutex.Lock()
-	defer indexStructure.indexMutex.Unlock()
-	//
-	results = traverseAndCollect(indexStructure.indexRootPointer, nullIndexPointer, indexStructure)
-	success = len(results) > 0
-	return
+func findPrefix(keyString string, indexStructure *Index) (prefixPointer, endPointer, nodesVisited int) {
+	// finds the node holding the last character of a (validated) search string -- every key starting with the string
+	// is there or below it -- and the 'base' of the left 'branch' it is in, where those keys end
+	prefixPointer = nullIndexPointer
+	endPointer = nullIndexPointer
+	keyLength := len(keyString)
+	indexPointer := indexStructure.indexRootPointer
+	i := 0
+	for {
+		if indexPointer == nullIndexPointer { // looked through it all and didn't find it
+			return
+		}
+		nodesVisited++
+		if indexStructure.node[indexPointer].indexType == decisionNode {
+			if keyString[i] <= indexStructure.node[indexPointer].keyCharacter {
+				endPointer = indexPointer // save the 'base' of the left 'branch'
+				indexPointer = indexStructure.node[indexPointer].leftPointer
+			} else {
+				indexPointer = indexStructure.node[indexPointer].rightPointer
+			}
+			continue
+		}
+		if keyString[i] != indexStructure.node[indexPointer].keyCharacter { // key doesn't match
+			return
+		}
+		if i+1 == keyLength { // last character in key -- found it
+			prefixPointer = indexPointer
+			return
+		} // otherwise more characters remain in the key
+		if indexStructure.node[indexPointer].indexType == indexTerminalNode ||
+			indexStructure.node[indexPointer].indexType == duplicateTerminalNode { // key doesn't match
+			return
+		} // must have been an 'indexKeyNode', 'duplicateKeyNode' or 'characterNode' so keep going
+		indexPointer = indexStructure.node[indexPointer].rightPointer
+		i++
+	}
 }
 
 //
 /////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
 //
 
-// Search returns an array of zero or many Element numbers based on the input search string
-//        a global search is performed -- the input string must be a root of a set of one or more existing keys
-//        (a blank, but not null, input is essentially the same as Scan)
-//        'success' is true if at least one result is found
-//
-func Search(keyInput string, indexStructure *Index) (success bool, results []int) {
-	indexStructure.indexMutex.Lock()
-	defer indexStructure.indexMutex.Unlock()
-	//
-	var startPointer, endPointer int
-	//
-	keyString, keyLength := validate(keyInput)
-	if keyLength == 0 { // nothing to look for
-		return
-	}
-	//
+func findKey(keyString string, indexStructure *Index) (keyPointer, nodesVisited int) {
+	// finds the 'index' or 'duplicate' node holding the elements of a key -- a precise search
+	keyPointer = nullIndexPointer
+	keyLength := len(keyString)
 	indexPointer := indexStructure.indexRootPointer
-	endPointer = nullIndexPointer
 	i := 0
-	for searching := true; searching; {
+	for {
 		if indexPointer == nullIndexPointer { // looked through it all and didn't find it
 			return
 		}
+		nodesVisited++
 		switch indexStructure.node[indexPointer].indexType {
-		case indexKeyNode, indexTerminalNode:
-			if keyString[i:i+1] == string(indexStructure.node[indexPointer].keyCharacter) {
-				if i+1 == keyLength { // last character in key
-					startPointer = indexPointer
-					searching = false // stop looking
-				} else { // more characters remain in key
-					if indexStructure.node[indexPointer].indexType == indexTerminalNode { // key doesn't match
-						return
-					} // must have been an 'indexKeyNode' so keep going
-					indexPointer = indexStructure.node[indexPointer].rightPointer
-					i++
-				}
+		case indexKeyNode, indexTerminalNode, duplicateKeyNode, duplicateTerminalNode:
+			if keyString[i] == indexStructure.node[indexPointer].keyCharacter {
+				if i+1 == keyLength { // last character in key -- found it
+					keyPointer = indexPointer
+					return
+				} // otherwise more characters remain in the key
+				if indexStructure.node[indexPointer].indexType == indexTerminalNode ||
+					indexStructure.node[indexPointer].indexType == duplicateTerminalNode { // key doesn't match
+					return
+				} // must have been an 'indexKeyNode' or 'duplicateKeyNode' so keep going
+				indexPointer = indexStructure.node[indexPointer].rightPointer
+				i++
 			} else { // key doesn't match
 				return
 			}
 		case decisionNode:
-			if keyString[i:i+1] <= string(indexStructure.node[indexPointer].keyCharacter) {
-				endPointer = indexPointer // save the 'base' of the left 'branch'
+			if keyString[i] <= indexStructure.node[indexPointer].keyCharacter {
 				indexPointer = indexStructure.node[indexPointer].leftPointer
 			} else {
 				indexPointer = indexStructure.node[indexPointer].rightPointer
 			}
-		case duplicateKeyNode, duplicateTerminalNode:
-			if keyString[i:i+1] == string(indexStructure.node[indexPointer].keyCharacter) {
-				if i+1 == keyLength { // last character in key
-					startPointer = indexStructure.node[indexPointer].leftPointer // move into the 'duplicates' branch
-					searching = false                                            // stop looking
-				} else { // more characters remain in key
-					if indexStructure.node[indexPointer].indexType == duplicateTerminalNode { // key doesn't match
-						return
-					} // must have been a 'duplicateKeyNode' so keep going
-					indexPointer = indexStructure.node[indexPointer].rightPointer
-					i++
-				}
-			} else { // key doesn't match
-				return
-			}
 		case characterNode:
-			if keyString[i:i+1] == string(indexStructure.node[indexPointer].keyCharacter) {
-				if i+1 == keyLength { // last character in key
-					startPointer = indexStructure.node[indexPointer].rightPointer
-					searching = false // stop looking
-				} else { // more characters remain in key so keep going
-					indexPointer = indexStructure.node[indexPointer].rightPointer
-					i++
+			if keyString[i] == indexStructure.node[indexPointer].keyCharacter {
+				if i+1 == keyLength { // last character in key -- but not at a terminal node -- key doesn't match
+					return
 				}
+				indexPointer = indexStructure.node[indexPointer].rightPointer
+				i++
 			} else { // key doesn't match
 				return
 			}
 		}
 	}
-	results = traverseAndCollect(startPointer, endPointer, indexStructure)
-	success = len(results) > 0
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func collectElements(keyPointer int, indexStructure *Index) (results []int, nodesVisited int) {
+	// collects the element (or all the 'duplicate' elements) held by the node found by 'findKey'
+	switch indexStructure.node[keyPointer].indexType {
+	case indexKeyNode, indexTerminalNode:
+		results = append(results, indexStructure.node[keyPointer].leftPointer)
+	case duplicateKeyNode, duplicateTerminalNode:
+		if indexStructure.postingLists {
+			postingPointer := indexStructure.node[keyPointer].leftPointer
+			results = appendPostingElements(results, indexStructure.postingList[postingPointer])
+		} else { // base of a 'duplicates' branch
+			results, nodesVisited = traverseAndCollect(indexStructure.node[keyPointer].leftPointer, keyPointer,
+				indexStructure)
+		}
+	}
 	return
 }
 
@@ -330,89 +648,79 @@ func Search(keyInput string, indexStructure *Index) (success bool, results []int
 /////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
 //
 
-// Select returns an array of zero or many Element numbers based on the input search string
-//        a precise search is performed -- the input string must match an existing key -- duplicates are included
-//        'success' is true if at least one result is found
+func (walker *keyWalker) collectElements(keyPointer int) (results []int) {
+	// 'collectElements' for a key the walk has reached -- counting the nodes it looks at as part of the walk
+	results, nodesVisited := collectElements(keyPointer, walker.indexStructure)
+	walker.nodesVisited += nodesVisited
+	return
+}
+
 //
-func Select(keyInput string, indexStructure *Index) (success bool, results []int) {
-	indexStructure.indexMutex.Lock()
-	defer indexStructure.indexMutex.Unlock()
-	//
-	keyString, keyLength := validate(keyInput)
-	if keyLength == 0 { // nothing to look for
-		return
-	}
-	//
-	indexPointer := indexStructure.indexRootPointer
-	i := 0
-	for {
-		if indexPointer == nullIndexPointer { // looked through it all and didn't find it
-			return
-		}
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func walkKeys(indexPointer int, keyPrefix []byte, lowCharacter, highCharacter byte, walker *keyWalker) (more bool) {
+	// visits every key in a 'branch' in order -- building up each key as it goes -- 'lowCharacter' to 'highCharacter'
+	// is the range the next character must be in (narrowed at each 'decisionNode') so unwanted branches can be skipped
+	indexStructure := walker.indexStructure
+	for indexPointer != nullIndexPointer {
+		walker.nodesVisited++
 		switch indexStructure.node[indexPointer].indexType {
-		case indexKeyNode, indexTerminalNode:
-			if keyString[i:i+1] == string(indexStructure.node[indexPointer].keyCharacter) {
-				if i+1 == keyLength { // last character in key
-					success = true // found a single match
-					results = append(results, indexStructure.node[indexPointer].leftPointer)
-					return
-				} // otherwise more characters remain in the key
-				if indexStructure.node[indexPointer].indexType == indexTerminalNode { // key doesn't match
-					return
-				} // must have been an 'indexKeyNode' so keep going
-				indexPointer = indexStructure.node[indexPointer].rightPointer
-				i++
-			} else { // key doesn't match
-				return
-			}
 		case decisionNode:
-			if keyString[i:i+1] <= string(indexStructure.node[indexPointer].keyCharacter) {
-				indexPointer = indexStructure.node[indexPointer].leftPointer
-			} else {
-				indexPointer = indexStructure.node[indexPointer].rightPointer
+			decisionCharacter := indexStructure.node[indexPointer].keyCharacter
+			if walker.keyFilter == nil || walker.keyFilter(keyPrefix, lowCharacter, decisionCharacter) {
+				if !walkKeys(indexStructure.node[indexPointer].leftPointer, keyPrefix, lowCharacter, decisionCharacter,
+					walker) {
+					return false
+				}
 			}
-		case duplicateKeyNode, duplicateTerminalNode:
-			if keyString[i:i+1] == string(indexStructure.node[indexPointer].keyCharacter) {
-				if i+1 == keyLength { // last character in key -- base of a 'duplicates' branch
-					success = true
-					results = traverseAndCollect(indexStructure.node[indexPointer].leftPointer, indexPointer,
-						indexStructure)
-					success = len(results) > 0
-					return
-				} // otherwise more characters remain in the key
-				if indexStructure.node[indexPointer].indexType == duplicateTerminalNode { // key doesn't match
-					return
-				} // must have been a 'duplicateKeyNode' so keep going
-				indexPointer = indexStructure.node[indexPointer].rightPointer
-				i++
-			} else { // key doesn't match
-				return
+			if decisionCharacter == highCharacter { // nothing can be on the right
+				return true
 			}
-		case characterNode:
-			if keyString[i:i+1] == string(indexStructure.node[indexPointer].keyCharacter) {
-				if i+1 == keyLength { // last character in key -- but not at a terminal node -- key doesn't match
-					return
+			lowCharacter = decisionCharacter + 1
+			if walker.keyFilter != nil && !walker.keyFilter(keyPrefix, lowCharacter, highCharacter) {
+				return true
+			}
+			indexPointer = indexStructure.node[indexPointer].rightPointer
+		default:
+			keyCharacter := indexStructure.node[indexPointer].keyCharacter
+			if walker.keyFilter != nil && !walker.keyFilter(keyPrefix, keyCharacter, keyCharacter) {
+				return true
+			}
+			keyPrefix = append(keyPrefix, keyCharacter)
+			switch indexStructure.node[indexPointer].indexType {
+			case indexKeyNode, duplicateKeyNode:
+				if !walker.keyVisit(keyPrefix, indexPointer) {
+					return false
 				}
-				indexPointer = indexStructure.node[indexPointer].rightPointer
-				i++
-			} else { // key doesn't match
-				return
+			case indexTerminalNode, duplicateTerminalNode: // the 'rightPointer' is a 'thread' -- end of the 'branch'
+				return walker.keyVisit(keyPrefix, indexPointer)
 			}
+			lowCharacter, highCharacter = 0, 255
+			indexPointer = indexStructure.node[indexPointer].rightPointer
 		}
 	}
+	return true
 }
 
 //
 /////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
 //
 
-// Count returns a count of how many Element numbers (keys) incuding duplicates, are contained in the supplied index
+func commonPrefixLength(firstBytes, secondBytes []byte) (commonLength int) {
+	for commonLength < len(firstBytes) && commonLength < len(secondBytes) &&
+		firstBytes[commonLength] == secondBytes[commonLength] {
+		commonLength++
+	}
+	return
+}
+
 //
-func Count(indexStructure *Index) (keyCount int) {
-	indexStructure.indexMutex.Lock()
-	defer indexStructure.indexMutex.Unlock()
-	//
-	keyCount = indexStructure.keyCount
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func newPrefixMemo[S any](startState S, nextState func(previousState S, keyCharacter byte) S) (memo prefixMemo[S]) {
+	memo = prefixMemo[S]{prefixStates: []S{startState}, nextState: nextState}
 	return
 }
 
@@ -420,19 +728,117 @@ func Count(indexStructure *Index) (keyCount int) {
 /////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
 //
 
-// Insert places the input string into the specified index structure along with the supplied "element" number
+func stateAfter[S any](keyPrefix []byte, memo *prefixMemo[S]) (state S) {
+	// the state after 'keyPrefix' -- the walk goes depth first so only the characters that differ from the last
+	// prefix need stepping through
+	commonLength := commonPrefixLength(memo.keyPrefix, keyPrefix)
+	memo.prefixStates = memo.prefixStates[:commonLength+1]
+	memo.keyPrefix = append(memo.keyPrefix[:commonLength], keyPrefix[commonLength:]...)
+	for i := commonLength; i < len(keyPrefix); i++ {
+		memo.prefixStates = append(memo.prefixStates, memo.nextState(memo.prefixStates[i], keyPrefix[i]))
+	}
+	state = memo.prefixStates[len(keyPrefix)]
+	return
+}
+
 //
-func Insert(keyInput string, keyElement int, indexStructure *Index) (success bool) {
-	indexStructure.indexMutex.Lock()
-	defer indexStructure.indexMutex.Unlock()
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func rangeFilter(fromString, toString string) (keyFilter func([]byte, byte, byte) bool) {
+	// a 'keyWalker' filter that skips the branches holding nothing from 'fromString' up to 'toString' -- an empty
+	// string leaves that end open
+	var boundBuffer []byte
+	keyFilter = func(keyPrefix []byte, lowCharacter, highCharacter byte) bool {
+		boundBuffer = append(append(boundBuffer[:0], keyPrefix...), highCharacter) // the highest keys it could lead to
+		fromLength := len(fromString)
+		if fromLength > len(boundBuffer) {
+			fromLength = len(boundBuffer)
+		}
+		if bytes.Compare(boundBuffer, []byte(fromString[:fromLength])) < 0 { // all below the range
+			return false
+		}
+		boundBuffer[len(boundBuffer)-1] = lowCharacter // the lowest key it could lead to
+		return toString == "" || bytes.Compare(boundBuffer, []byte(toString)) <= 0
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func rangeKeys(fromString, toString string, indexStructure *Index) (results []int, nodesVisited int) {
+	// collects the elements of every key from 'fromString' up to and including 'toString' (validated) -- an empty
+	// string leaves that end open
+	walker := keyWalker{indexStructure: indexStructure, keyFilter: rangeFilter(fromString, toString)}
+	walker.keyVisit = func(keyString []byte, keyPointer int) bool {
+		if toString != "" && string(keyString) > toString { // past the end -- no more to find
+			return false
+		}
+		if string(keyString) >= fromString {
+			results = append(results, walker.collectElements(keyPointer)...)
+		}
+		return true
+	}
+	walkKeys(indexStructure.indexRootPointer, nil, 0, 255, &walker)
+	nodesVisited = walker.nodesVisited
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func extend(keyString string, keyElement, nextBranchBasePointer int, indexStructure *Index) (extensionPointer int) {
+	// creates key nodes in reverse order -- points the leaf node at the next 'branch' sent in as a parameter
+	var newIndexNode indexNode
+	var newIndexPointer int
+	//
+	keyLength := len(keyString)
+	extensionPointer = nextBranchBasePointer
 	//
+	for i := keyLength - 1; i >= 0; i-- {
+		// create new index node
+		byteArray := []byte(keyString[i : i+1])
+		newIndexNode.keyCharacter = byteArray[0]
+		newIndexNode.rightPointer = extensionPointer
+		if i == keyLength-1 { // leaf node
+			newIndexNode.indexType = indexTerminalNode
+			newIndexNode.leftPointer = keyElement
+		} else { // character node
+			newIndexNode.indexType = characterNode
+			newIndexNode.leftPointer = nullIndexPointer
+		}
+		//
+		if indexStructure.deletedRootPointer == nullIndexPointer { // append to end of indexStructure
+			indexStructure.node = append(indexStructure.node, newIndexNode)
+			newIndexPointer = len(indexStructure.node) - 1
+		} else { // use up one of the 'deleted' nodes
+			newIndexPointer = indexStructure.deletedRootPointer
+			indexStructure.deletedRootPointer = indexStructure.node[newIndexPointer].rightPointer
+			indexStructure.node[newIndexPointer] = newIndexNode
+		}
+		extensionPointer = newIndexPointer
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func insertKey(keyString string, keyElement, startPointer, startCharacter int,
+	indexStructure *Index) (success bool, nodesVisited int) {
+	// places a (validated) key in the index -- starting the search at 'startPointer' which holds the 'startCharacter'
+	// of the key -- normally the 'root' and the first character
 	var decisionIndexPointer, nextBranchBasePointer int
 	//
-	keyString, keyLength := validate(keyInput)
-	if keyLength == 0 { // nothing to look for
+	if indexStructure.readOnly { // a 'snapshot' can't be changed
 		return
 	}
-	//
+	copyOnWrite(indexStructure)
+	keyLength := len(keyString)
 	if indexStructure.indexRootPointer == nullIndexPointer { // no index so put the key straight into the structure
 		indexStructure.indexRootPointer = extend(keyString, keyElement, nullIndexPointer, indexStructure)
 		indexStructure.keyCount++
@@ -441,17 +847,31 @@ func Insert(keyInput string, keyElement int, indexStructure *Index) (success boo
 	}
 	//
 	previousIndexPointer := nullIndexPointer
-	indexPointer := indexStructure.indexRootPointer
+	indexPointer := startPointer
 	duplicateFlag := false
-	i := 0
+	i := startCharacter
 	for searching := true; searching; {
+		nodesVisited++
 		switch indexStructure.node[indexPointer].indexType {
 		case indexKeyNode, indexTerminalNode:
-			if keyString[i:i+1] == string(indexStructure.node[indexPointer].keyCharacter) {
+			if keyString[i] == indexStructure.node[indexPointer].keyCharacter {
 				if i+1 == keyLength { // last character in key
 					if keyElement == indexStructure.node[indexPointer].leftPointer { // new key EXACTLY same as existing
 						return // key value and key element are the same so do nothing
 					}
+					if indexStructure.postingLists { // start a list with both elements -- no 'duplicates' branch
+						keyElements := []int{indexStructure.node[indexPointer].leftPointer, keyElement}
+						sort.Ints(keyElements)
+						if indexStructure.node[indexPointer].indexType == indexKeyNode {
+							indexStructure.node[indexPointer].indexType = duplicateKeyNode
+						} else {
+							indexStructure.node[indexPointer].indexType = duplicateTerminalNode
+						}
+						indexStructure.node[indexPointer].leftPointer = newPostingList(keyElements, indexStructure)
+						indexStructure.keyCount++
+						success = true
+						return
+					}
 					keyString, keyLength = decimaliseNumber(indexStructure.node[indexPointer].leftPointer)
 					linkIndexPointer := // create a duplicate structure and then find where to insert the new key
 						extend(keyString, indexStructure.node[indexPointer].leftPointer, indexPointer, indexStructure)
@@ -487,14 +907,22 @@ func Insert(keyInput string, keyElement int, indexStructure *Index) (success boo
 			}
 		case decisionNode:
 			previousIndexPointer = indexPointer
-			if keyString[i:i+1] <= string(indexStructure.node[indexPointer].keyCharacter) {
+			if keyString[i] <= indexStructure.node[indexPointer].keyCharacter {
 				indexPointer = indexStructure.node[indexPointer].leftPointer
 			} else {
 				indexPointer = indexStructure.node[indexPointer].rightPointer
 			}
 		case duplicateKeyNode, duplicateTerminalNode:
-			if keyString[i:i+1] == string(indexStructure.node[indexPointer].keyCharacter) {
+			if keyString[i] == indexStructure.node[indexPointer].keyCharacter {
 				if i+1 == keyLength { // last character in key -- need to look through the 'duplicates' branch
+					if indexStructure.postingLists { // just add it to the list
+						success = addToPostingList(indexStructure.node[indexPointer].leftPointer, keyElement,
+							indexStructure)
+						if success {
+							indexStructure.keyCount++
+						}
+						return
+					}
 					duplicateFlag = true
 					keyString, keyLength = decimaliseNumber(keyElement) // start searching the 'duplicates' branch
 					i = 0
@@ -520,7 +948,7 @@ func Insert(keyInput string, keyElement int, indexStructure *Index) (success boo
 				break
 			}
 		case characterNode:
-			if keyString[i:i+1] == string(indexStructure.node[indexPointer].keyCharacter) {
+			if keyString[i] == indexStructure.node[indexPointer].keyCharacter {
 				if i+1 == keyLength { // last character in key -- new key is a subset
 					indexStructure.node[indexPointer].indexType = indexKeyNode
 					indexStructure.node[indexPointer].leftPointer = keyElement
@@ -549,7 +977,7 @@ func Insert(keyInput string, keyElement int, indexStructure *Index) (success boo
 		indexStructure.node[decisionIndexPointer] = newIndexNode
 	}
 	// work out which way to 'attach' the new 'branch' to the 'decisionNode'
-	if keyString[i:i+1] > string(indexStructure.node[indexPointer].keyCharacter) {
+	if keyString[i] > indexStructure.node[indexPointer].keyCharacter {
 		threadIndexPointer := indexPointer
 		for !(indexStructure.node[threadIndexPointer].indexType == indexTerminalNode ||
 			indexStructure.node[threadIndexPointer].indexType == duplicateTerminalNode) {
@@ -563,7 +991,7 @@ func Insert(keyInput string, keyElement int, indexStructure *Index) (success boo
 	//
 	linkIndexPointer := extend(keyString[i:], keyElement, nextBranchBasePointer, indexStructure)
 	// fill in the 'decisionNode'
-	if keyString[i:i+1] < string(indexStructure.node[indexPointer].keyCharacter) {
+	if keyString[i] < indexStructure.node[indexPointer].keyCharacter {
 		indexStructure.node[decisionIndexPointer].leftPointer = linkIndexPointer
 		byteArray := []byte(keyString[i : i+1])
 		indexStructure.node[decisionIndexPointer].keyCharacter = byteArray[0]
@@ -578,7 +1006,7 @@ func Insert(keyInput string, keyElement int, indexStructure *Index) (success boo
 		indexStructure.indexRootPointer = decisionIndexPointer
 	} else {
 		if indexStructure.node[previousIndexPointer].indexType == decisionNode &&
-			keyString[i:i+1] <= string(indexStructure.node[previousIndexPointer].keyCharacter) ||
+			keyString[i] <= indexStructure.node[previousIndexPointer].keyCharacter ||
 			indexStructure.node[previousIndexPointer].indexType == duplicateTerminalNode ||
 			(indexStructure.node[previousIndexPointer].indexType == duplicateKeyNode && duplicateFlag) {
 			indexStructure.node[previousIndexPointer].leftPointer = decisionIndexPointer
@@ -595,17 +1023,13 @@ func Insert(keyInput string, keyElement int, indexStructure *Index) (success boo
 /////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
 //
 
-// Delete removes a key and its associated "index-number" from the supplied index
-//
-func Delete(keyInput string, keyElement int, indexStructure *Index) (success bool) {
-	indexStructure.indexMutex.Lock()
-	defer indexStructure.indexMutex.Unlock()
-	//
-	keyString, keyLength := validate(keyInput)
-	if keyLength == 0 { // nothing to look for
+func deleteKey(keyString string, keyElement int, indexStructure *Index) (success bool, nodesVisited int) {
+	// removes a (validated) key and its element from the index
+	if indexStructure.readOnly { // a 'snapshot' can't be changed
 		return
 	}
-	//
+	copyOnWrite(indexStructure)
+	keyLength := len(keyString)
 	if indexStructure.indexRootPointer == nullIndexPointer { // no index
 		success = false
 		return
@@ -620,6 +1044,7 @@ func Delete(keyInput string, keyElement int, indexStructure *Index) (success boo
 	deleteBranch := null
 	i := 0
 	for searching := true; searching; {
+		nodesVisited++
 		if indexStructure.node[indexPointer].indexType == decisionNode ||
 			indexStructure.node[indexPointer].indexType == duplicateKeyNode ||
 			indexStructure.node[indexPointer].indexType == indexKeyNode { // branch above may/will be deleted
@@ -628,7 +1053,7 @@ func Delete(keyInput string, keyElement int, indexStructure *Index) (success boo
 		}
 		switch indexStructure.node[indexPointer].indexType {
 		case indexKeyNode, indexTerminalNode:
-			if keyString[i:i+1] == string(indexStructure.node[indexPointer].keyCharacter) {
+			if keyString[i] == indexStructure.node[indexPointer].keyCharacter {
 				if i+1 == keyLength { // last character in key -- key match
 					if indexStructure.node[indexPointer].leftPointer != keyElement { // element doesn't
 						success = false
@@ -650,7 +1075,7 @@ func Delete(keyInput string, keyElement int, indexStructure *Index) (success boo
 			}
 		case decisionNode:
 			previousIndexPointer = indexPointer
-			if keyString[i:i+1] <= string(indexStructure.node[indexPointer].keyCharacter) {
+			if keyString[i] <= indexStructure.node[indexPointer].keyCharacter {
 				indexPointer = indexStructure.node[indexPointer].leftPointer
 				deleteBranch = left // remember which side of a 'decisionNode' (if any) is going to be deleted
 			} else {
@@ -658,8 +1083,29 @@ func Delete(keyInput string, keyElement int, indexStructure *Index) (success boo
 				deleteBranch = right // remember which side of a 'decisionNode' (if any) is going to be deleted
 			}
 		case duplicateKeyNode, duplicateTerminalNode:
-			if keyString[i:i+1] == string(indexStructure.node[indexPointer].keyCharacter) {
+			if keyString[i] == indexStructure.node[indexPointer].keyCharacter {
 				if i+1 == keyLength { // last character in key -- base of a 'duplicates' branch -- key match
+					if indexStructure.postingLists { // just take it out of the list
+						postingPointer := indexStructure.node[indexPointer].leftPointer
+						if !removeFromPostingList(postingPointer, keyElement, indexStructure) { // element doesn't
+							success = false
+							return
+						}
+						if blocks := indexStructure.postingList[postingPointer]; len(blocks) == 1 &&
+							len(blocks[0]) == 1 { // no longer a 'duplicate' node
+							if indexStructure.node[indexPointer].indexType == duplicateKeyNode {
+								indexStructure.node[indexPointer].indexType = indexKeyNode
+							} else {
+								indexStructure.node[indexPointer].indexType = indexTerminalNode
+							}
+							indexStructure.node[indexPointer].leftPointer = blocks[0][0] // the element that's left
+							indexStructure.postingList[postingPointer] = nil
+							indexStructure.deletedPostings = append(indexStructure.deletedPostings, postingPointer)
+						}
+						indexStructure.keyCount--
+						success = true
+						return
+					}
 					duplicateNodePointer = indexPointer // remember the base of the 'duplicates' branch
 					i = 0
 					keyString, keyLength = decimaliseNumber(keyElement) // create a new search string
@@ -679,7 +1125,7 @@ func Delete(keyInput string, keyElement int, indexStructure *Index) (success boo
 				return
 			}
 		case characterNode:
-			if keyString[i:i+1] == string(indexStructure.node[indexPointer].keyCharacter) {
+			if keyString[i] == indexStructure.node[indexPointer].keyCharacter {
 				if i+1 == keyLength { // last character in key -- key not found
 					success = false
 					return
@@ -897,10 +1343,548 @@ func Delete(keyInput string, keyElement int, indexStructure *Index) (success boo
 /////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
 //
 
+func countInHistogram(histogram []int, value int) []int {
+	for len(histogram) <= value {
+		histogram = append(histogram, 0)
+	}
+	histogram[value]++
+	return histogram
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func measureBranches(indexPointer int, keyPrefix []byte, pathLength int, pathLengthTotal *int, indexStructure *Index,
+	result *Statistic) (branchCount int) {
+	// adds the shape of the branches a set of 'decisionNodes' chooses between to the 'result' -- 'pathLength' is the
+	// nodes visited to get here -- returns how many branches there are (how many characters can come next)
+	thisNode := indexStructure.node[indexPointer]
+	if thisNode.indexType == decisionNode {
+		branchCount = measureBranches(thisNode.leftPointer, keyPrefix, pathLength+1, pathLengthTotal, indexStructure,
+			result)
+		branchCount += measureBranches(thisNode.rightPointer, keyPrefix, pathLength+1, pathLengthTotal,
+			indexStructure, result)
+		return
+	}
+	branchCount = 1
+	pathLength++
+	keyPrefix = append(keyPrefix, thisNode.keyCharacter)
+	switch thisNode.indexType {
+	case indexKeyNode, indexTerminalNode:
+		result.KeyLengths = countInHistogram(result.KeyLengths, len(keyPrefix))
+		result.MaximumPathLength = max(result.MaximumPathLength, pathLength)
+		*pathLengthTotal += pathLength
+	case duplicateKeyNode, duplicateTerminalNode:
+		result.KeyLengths = countInHistogram(result.KeyLengths, len(keyPrefix))
+		result.MaximumPathLength = max(result.MaximumPathLength, pathLength)
+		*pathLengthTotal += pathLength
+		if duplicateElements, _ := collectElements(indexPointer, indexStructure); len(duplicateElements) >
+			result.LargestDuplicateCount {
+			result.LargestDuplicateCount = len(duplicateElements)
+			result.LargestDuplicateKey = slices.Clone(keyPrefix)
+		}
+	}
+	nextBranches := 0 // a 'terminal' node has nothing after it
+	if thisNode.indexType != indexTerminalNode && thisNode.indexType != duplicateTerminalNode {
+		nextBranches = measureBranches(thisNode.rightPointer, keyPrefix, pathLength, pathLengthTotal, indexStructure,
+			result)
+	}
+	result.BranchingFactors = countInHistogram(result.BranchingFactors, nextBranches)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Initialise sets up an index structure -- must be executed before anything else is performed
+//
+func Initialise(indexStructure *Index) {
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	indexStructure.indexRootPointer = nullIndexPointer
+	indexStructure.deletedRootPointer = nullIndexPointer
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// SetCollation sets the conversion applied to every key before it is stored or looked for -- a nil collation means
+//              keys are stored as they are (and ordered byte by byte)
+//              it can only be set on an empty (just initialised) index -- 'success' is false otherwise
+//
+func SetCollation(collation Collation, indexStructure *Index) (success bool) {
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	if indexStructure.indexRootPointer != nullIndexPointer || indexStructure.readOnly { // keys stored without it
+		return
+	}
+	indexStructure.collation = collation
+	success = true
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// SetPostingLists chooses how the elements of duplicate keys are held -- 'enabled' keeps them as sorted lists of
+//                 numbers hanging off the 'duplicate' node (returned in numeric order) rather than as a 'duplicates'
+//                 branch of one node per digit (returned in character order) -- far quicker to read back, smaller
+//                 and quicker to change for many duplicates -- see BenchmarkDuplicates
+//                 a list is held in blocks so a change only moves part of it -- and is copied the first time it is
+//                 changed after a Snapshot (or while a transaction is open) so the snapshot keeps what it had
+//                 it can only be set on an empty (just initialised) index -- 'success' is false otherwise
+//
+func SetPostingLists(enabled bool, indexStructure *Index) (success bool) {
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	if indexStructure.indexRootPointer != nullIndexPointer || indexStructure.readOnly { // existing duplicates differ
+		return
+	}
+	indexStructure.postingLists = enabled
+	indexStructure.postingList = nil
+	indexStructure.postingOwned = nil
+	indexStructure.deletedPostings = nil
+	success = true
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Scan returns an array of zero or many Element numbers based on the total content of the supplied index
+//      'success' is true if at least one result is found
+//
+func Scan(indexStructure *Index) (success bool, results []int) {
+	timer := lockIndex(context.Background(), OperationScan, 0, indexStructure)
+	defer func() { unlockIndex(timer, success, len(results), indexStructure) }()
+	//
+	results, timer.nodesVisited = traverseAndCollect(indexStructure.indexRootPointer, nullIndexPointer, indexStructure)
+	success = len(results) > 0
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Search returns an array of zero or many Element numbers based on the input search string
+//        a global search is performed -- the input string must be a root of a set of one or more existing keys
+//        (a blank, but not null, input is essentially the same as Scan)
+//        'success' is true if at least one result is found
+//
+func Search(keyInput string, indexStructure *Index) (success bool, results []int) {
+	timer := lockIndex(context.Background(), OperationSearch, len(keyInput), indexStructure)
+	defer func() { unlockIndex(timer, success, len(results), indexStructure) }()
+	//
+	keyString, keyLength := validate(keyInput, indexStructure.collation)
+	if keyLength == 0 { // nothing to look for
+		return
+	}
+	//
+	results, timer.nodesVisited = searchKey(keyString, indexStructure)
+	success = len(results) > 0
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// ScanContext is Scan but gives up if the context is cancelled (or times out) part way through, or while it waits
+//             for another call to unlock the index -- 'err' is then ctx.Err() and there are no results
+//
+func ScanContext(ctx context.Context, indexStructure *Index) (success bool, results []int, err error) {
+	timer := lockIndex(ctx, OperationScan, 0, indexStructure)
+	defer func() { unlockIndex(timer, success, len(results), indexStructure) }()
+	//
+	if err = timer.lockErr; err != nil { // cancelled while waiting for the index
+		return
+	}
+	results, timer.nodesVisited, err = traverseAndCollectContext(ctx, indexStructure.indexRootPointer, nullIndexPointer,
+		indexStructure)
+	success = len(results) > 0
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// SearchContext is Search but gives up if the context is cancelled (or times out) part way through, or while it
+//               waits for another call to unlock the index -- 'err' is then ctx.Err() and there are no results
+//
+func SearchContext(ctx context.Context, keyInput string, indexStructure *Index) (success bool, results []int,
+	err error) {
+	timer := lockIndex(ctx, OperationSearch, len(keyInput), indexStructure)
+	defer func() { unlockIndex(timer, success, len(results), indexStructure) }()
+	//
+	if err = timer.lockErr; err != nil { // cancelled while waiting for the index
+		return
+	}
+	keyString, keyLength := validate(keyInput, indexStructure.collation)
+	if keyLength == 0 { // nothing to look for
+		return
+	}
+	//
+	results, timer.nodesVisited, err = searchKeyContext(ctx, keyString, indexStructure)
+	success = len(results) > 0
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Range returns an array of zero or many Element numbers for every key from the first input string up to and
+//       including the second, in key order -- a blank input leaves that end of the range open
+//       'success' is true if at least one result is found
+//
+func Range(fromInput, toInput string, indexStructure *Index) (success bool, results []int) {
+	timer := lockIndex(context.Background(), OperationRange, len(fromInput), indexStructure)
+	defer func() { unlockIndex(timer, success, len(results), indexStructure) }()
+	//
+	fromString, _ := validate(fromInput, indexStructure.collation)
+	toString, _ := validate(toInput, indexStructure.collation)
+	if toString != "" && fromString > toString { // nothing can be in the range
+		return
+	}
+	results, timer.nodesVisited = rangeKeys(fromString, toString, indexStructure)
+	success = len(results) > 0
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// ScanKeys returns up to 'keyLimit' keys and their Element numbers, in key order, starting with the first key after
+//          'afterKey' -- which is a key as stored (the Key of the last entry of the previous call) or empty to start
+//          at the beginning -- so the whole index can be read a page at a time
+//          'more' is true if there are keys after those returned
+//
+func ScanKeys(afterKey string, keyLimit int, indexStructure *Index) (entries []KeyEntry, more bool) {
+	timer := lockIndex(context.Background(), OperationScan, len(afterKey), indexStructure)
+	defer func() { unlockIndex(timer, len(entries) > 0, len(entries), indexStructure) }()
+	//
+	if keyLimit <= 0 {
+		return
+	}
+	walker := keyWalker{indexStructure: indexStructure, keyFilter: rangeFilter(afterKey, "")}
+	walker.keyVisit = func(keyString []byte, keyPointer int) bool {
+		if afterKey != "" && string(keyString) <= afterKey { // returned last time
+			return true
+		}
+		if len(entries) == keyLimit { // one more than wanted
+			more = true
+			return false
+		}
+		entries = append(entries, KeyEntry{string(keyString), walker.collectElements(keyPointer)})
+		return true
+	}
+	walkKeys(indexStructure.indexRootPointer, nil, 0, 255, &walker)
+	timer.nodesVisited = walker.nodesVisited
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Entries returns an iterator over the keys after 'afterKey' (as for ScanKeys -- empty for every key) and their
+//         Element numbers in key order -- the keys are found a few at a time as the loop asks for them
+//         the index is only locked while each few are found (as by ScanKeys) so it can carry on changing however
+//         long the loop takes -- a key changed during the loop is seen as it is when the loop reaches it
+//
+func Entries(afterKey string, indexStructure *Index) iter.Seq[KeyEntry] {
+	return func(yield func(KeyEntry) bool) {
+		for {
+			entries, more := ScanKeys(afterKey, entriesPerLock, indexStructure)
+			for _, entry := range entries {
+				if !yield(entry) {
+					return
+				}
+			}
+			if !more {
+				return
+			}
+			afterKey = entries[len(entries)-1].Key
+		}
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Select returns an array of zero or many Element numbers based on the input search string
+//        a precise search is performed -- the input string must match an existing key -- duplicates are included
+//        'success' is true if at least one result is found
+//
+func Select(keyInput string, indexStructure *Index) (success bool, results []int) {
+	timer := lockIndex(context.Background(), OperationSelect, len(keyInput), indexStructure)
+	defer func() { unlockIndex(timer, success, len(results), indexStructure) }()
+	//
+	keyString, keyLength := validate(keyInput, indexStructure.collation)
+	if keyLength == 0 { // nothing to look for
+		return
+	}
+	//
+	indexPointer, nodesVisited := findKey(keyString, indexStructure)
+	timer.nodesVisited = nodesVisited
+	if indexPointer == nullIndexPointer { // key doesn't match
+		return
+	}
+	results, nodesVisited = collectElements(indexPointer, indexStructure)
+	timer.nodesVisited += nodesVisited
+	success = len(results) > 0
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Snapshot returns a read-only copy of the index as it is at the moment of the call -- every query (Scan, Search,
+//          Select, Count, Statistics ...) can be used on it while the original carries on changing
+//          the copy shares the node array -- the next change to the original takes its own copy of the array first
+//          Insert, Delete and the other changes always fail on a snapshot
+//
+func Snapshot(indexStructure *Index) (snapshot *Index) {
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	snapshot = takeSnapshot(indexStructure)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func takeSnapshot(indexStructure *Index) (snapshot *Index) {
+	// makes a read-only copy of the (locked) index -- sharing its node array until the next change
+	snapshot = &Index{
+		indexRootPointer:   indexStructure.indexRootPointer,
+		node:               indexStructure.node,
+		deletedRootPointer: indexStructure.deletedRootPointer,
+		keyCount:           indexStructure.keyCount,
+		collation:          indexStructure.collation,
+		postingLists:       indexStructure.postingLists,
+		postingList:        indexStructure.postingList,
+		deletedPostings:    indexStructure.deletedPostings,
+		nodeShared:         true,
+		readOnly:           true,
+	}
+	indexStructure.nodeShared = true
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Count returns a count of how many Element numbers (keys) incuding duplicates, are contained in the supplied index
+//
+func Count(indexStructure *Index) (keyCount int) {
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	keyCount = indexStructure.keyCount
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Insert places the input string into the specified index structure along with the supplied "element" number
+//
+func Insert(keyInput string, keyElement int, indexStructure *Index) (success bool) {
+	timer := lockIndex(context.Background(), OperationInsert, len(keyInput), indexStructure)
+	defer func() { unlockIndex(timer, success, 0, indexStructure) }()
+	//
+	keyString, keyLength := validate(keyInput, indexStructure.collation)
+	if keyLength == 0 { // nothing to look for
+		return
+	}
+	if indexStructure.writeAheadLog != nil &&
+		!logChanges([]logRecord{{insertOperation, keyString, keyElement}}, indexStructure) {
+		return
+	}
+	//
+	success, timer.nodesVisited = insertKey(keyString, keyElement, indexStructure.indexRootPointer, 0, indexStructure)
+	if success {
+		publishChange(insertOperation, keyString, keyElement, indexStructure)
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// InsertMany places the input string into the specified index structure along with every one of the supplied
+//            "element" numbers -- the key is only looked for once however many elements there are
+//            'newCount' is how many of the elements were not already held against the key
+//            only an index using posting lists merges all of the elements in one pass -- with 'duplicates' branches
+//            each element is still walked into the branch on its own, so the saving is just the one key look-up
+//
+func InsertMany(keyInput string, keyElements []int, indexStructure *Index) (newCount int) {
+	timer := lockIndex(context.Background(), OperationInsert, len(keyInput), indexStructure)
+	defer func() { unlockIndex(timer, newCount > 0, 0, indexStructure) }()
+	//
+	keyString, keyLength := validate(keyInput, indexStructure.collation)
+	if keyLength == 0 || len(keyElements) == 0 || indexStructure.readOnly { // nothing to look for (or can't change)
+		return
+	}
+	//
+	sortedElements := append([]int(nil), keyElements...)
+	sort.Ints(sortedElements)
+	uniqueCount := 0
+	for i, keyElement := range sortedElements { // drop any repeats
+		if i == 0 || keyElement != sortedElements[uniqueCount-1] {
+			sortedElements[uniqueCount] = keyElement
+			uniqueCount++
+		}
+	}
+	sortedElements = sortedElements[:uniqueCount]
+	if indexStructure.writeAheadLog != nil {
+		records := make([]logRecord, len(sortedElements))
+		for i, keyElement := range sortedElements {
+			records[i] = logRecord{insertOperation, keyString, keyElement}
+		}
+		if !logChanges(records, indexStructure) {
+			return
+		}
+	}
+	//
+	keyPointer, nodesVisited := findKey(keyString, indexStructure)
+	timer.nodesVisited = nodesVisited
+	if keyPointer == nullIndexPointer { // a new key -- put it in with the first element
+		_, nodesVisited = insertKey(keyString, sortedElements[0], indexStructure.indexRootPointer, 0, indexStructure)
+		timer.nodesVisited += nodesVisited
+		publishChange(insertOperation, keyString, sortedElements[0], indexStructure)
+		newCount++
+		sortedElements = sortedElements[1:]
+		keyPointer, nodesVisited = findKey(keyString, indexStructure)
+		timer.nodesVisited += nodesVisited
+	}
+	//
+	if !indexStructure.postingLists { // each element is walked into the 'duplicates' branch on its own
+		for _, keyElement := range sortedElements {
+			inserted, nodesVisited := insertKey(keyString, keyElement, keyPointer, keyLength-1, indexStructure)
+			timer.nodesVisited += nodesVisited
+			if inserted {
+				publishChange(insertOperation, keyString, keyElement, indexStructure)
+				newCount++
+			}
+		}
+		return
+	}
+	// merge the elements with those already held
+	copyOnWrite(indexStructure)
+	var heldElements []int
+	switch indexStructure.node[keyPointer].indexType {
+	case indexKeyNode, indexTerminalNode:
+		heldElements = []int{indexStructure.node[keyPointer].leftPointer}
+	case duplicateKeyNode, duplicateTerminalNode:
+		postingPointer := indexStructure.node[keyPointer].leftPointer
+		heldElements = appendPostingElements(nil, indexStructure.postingList[postingPointer])
+	}
+	mergedElements := make([]int, 0, len(heldElements)+len(sortedElements))
+	var addedElements []int
+	i, j := 0, 0
+	for i < len(heldElements) || j < len(sortedElements) {
+		switch {
+		case j == len(sortedElements) || (i < len(heldElements) && heldElements[i] < sortedElements[j]):
+			mergedElements = append(mergedElements, heldElements[i])
+			i++
+		case i == len(heldElements) || sortedElements[j] < heldElements[i]:
+			mergedElements = append(mergedElements, sortedElements[j])
+			addedElements = append(addedElements, sortedElements[j])
+			j++
+		default: // already held
+			mergedElements = append(mergedElements, heldElements[i])
+			i++
+			j++
+		}
+	}
+	addedCount := len(addedElements)
+	if addedCount == 0 {
+		return
+	}
+	switch indexStructure.node[keyPointer].indexType {
+	case indexKeyNode:
+		indexStructure.node[keyPointer].indexType = duplicateKeyNode
+		indexStructure.node[keyPointer].leftPointer = newPostingList(mergedElements, indexStructure)
+	case indexTerminalNode:
+		indexStructure.node[keyPointer].indexType = duplicateTerminalNode
+		indexStructure.node[keyPointer].leftPointer = newPostingList(mergedElements, indexStructure)
+	default: // already a 'duplicate' node
+		postingPointer := indexStructure.node[keyPointer].leftPointer
+		indexStructure.postingList[postingPointer] = postingBlocksOf(mergedElements)
+		indexStructure.postingOwned[postingPointer] = true
+	}
+	indexStructure.keyCount += addedCount
+	newCount += addedCount
+	for _, keyElement := range addedElements {
+		publishChange(insertOperation, keyString, keyElement, indexStructure)
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Delete removes a key and its associated "index-number" from the supplied index
+//
+func Delete(keyInput string, keyElement int, indexStructure *Index) (success bool) {
+	timer := lockIndex(context.Background(), OperationDelete, len(keyInput), indexStructure)
+	defer func() { unlockIndex(timer, success, 0, indexStructure) }()
+	//
+	keyString, keyLength := validate(keyInput, indexStructure.collation)
+	if keyLength == 0 { // nothing to look for
+		return
+	}
+	if indexStructure.writeAheadLog != nil &&
+		!logChanges([]logRecord{{deleteOperation, keyString, keyElement}}, indexStructure) {
+		return
+	}
+	//
+	success, timer.nodesVisited = deleteKey(keyString, keyElement, indexStructure)
+	if success {
+		publishChange(deleteOperation, keyString, keyElement, indexStructure)
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
 // Statistics scans the specified index structure and returns a structure of counts of the different node types
+//            along with the memory the node array takes and the shape of the index (key lengths, how the keys branch,
+//            the largest set of duplicates and how many nodes it takes to find a key)
 //            the first parameter returned is an array of numbers -- the second is a json string for human consumption
 //
 func Statistics(indexStructure *Index) (result Statistic, statsString string) {
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
 	var stack []int
 	stackPointer := 0
 	direction := left
@@ -945,7 +1929,12 @@ func Statistics(indexStructure *Index) (result Statistic, statsString string) {
 			}
 			//
 		case duplicateKeyNode:
-			if direction == left {
+			if indexStructure.postingLists { // no 'duplicates' branch to go into
+				result.DuplicateKeyNodeCount++
+				stack, stackPointer = pushStack(stack, indexPointer, stackPointer)
+				indexPointer = indexStructure.node[indexPointer].rightPointer
+				direction = left
+			} else if direction == left {
 				result.DuplicateKeyNodeCount++
 				stack, stackPointer = pushStack(stack, indexPointer, stackPointer)
 				indexPointer = indexStructure.node[indexPointer].leftPointer
@@ -966,7 +1955,17 @@ func Statistics(indexStructure *Index) (result Statistic, statsString string) {
 			direction = right
 			//
 		case duplicateTerminalNode:
-			if direction == left {
+			if indexStructure.postingLists { // no 'duplicates' branch to go into
+				result.DuplicateTerminalNodeCount++
+				stack, stackPointer = pushStack(stack, indexPointer, stackPointer)
+				indexPointer = indexStructure.node[indexPointer].rightPointer
+				if indexPointer != nullIndexPointer { // reset the stack
+					for stackPointer = 0; stack[stackPointer] != indexPointer; stackPointer++ {
+					}
+					stackPointer++
+				}
+				direction = right
+			} else if direction == left {
 				result.DuplicateTerminalNodeCount++
 				stack, stackPointer = pushStack(stack, indexPointer, stackPointer)
 				indexPointer = indexStructure.node[indexPointer].leftPointer
@@ -987,6 +1986,21 @@ func Statistics(indexStructure *Index) (result Statistic, statsString string) {
 	for x := indexStructure.deletedRootPointer; x != nullIndexPointer; x = indexStructure.node[x].rightPointer {
 		result.Deleted++
 	}
+	result.NodeBytes = cap(indexStructure.node) * int(unsafe.Sizeof(indexNode{}))
+	if len(indexStructure.node) > 0 {
+		result.DeletedRatio = float64(result.Deleted) / float64(len(indexStructure.node))
+	}
+	if indexStructure.indexRootPointer != nullIndexPointer {
+		pathLengthTotal := 0
+		rootBranches := measureBranches(indexStructure.indexRootPointer, nil, 0, &pathLengthTotal, indexStructure,
+			&result)
+		result.BranchingFactors = countInHistogram(result.BranchingFactors, rootBranches)
+		keyTotal := 0
+		for _, keyCount := range result.KeyLengths {
+			keyTotal += keyCount
+		}
+		result.AveragePathLength = float64(pathLengthTotal) / float64(keyTotal)
+	}
 	stats, _ := json.Marshal(result)
 	statsString = string(stats)
 	return
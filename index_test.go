@@ -0,0 +1,444 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var duplicateLayouts = []struct {
+	name         string
+	postingLists bool
+}{
+	{"duplicates branch", false},
+	{"posting lists", true},
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func newDuplicatesIndex(tb testing.TB, postingLists bool, keyString string, elementCount int) (indexStructure *Index) {
+	indexStructure = new(Index)
+	Initialise(indexStructure)
+	if !SetPostingLists(postingLists, indexStructure) {
+		tb.Fatal("SetPostingLists failed on an empty index")
+	}
+	for keyElement := range elementCount {
+		Insert(keyString, keyElement, indexStructure)
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func selectSorted(keyString string, indexStructure *Index) (results []int) {
+	// the duplicates branch returns the elements in character order -- so sort them to compare
+	_, results = Select(keyString, indexStructure)
+	slices.Sort(results)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestSetPostingListsOnPopulatedIndex(t *testing.T) {
+	for _, layout := range duplicateLayouts {
+		t.Run(layout.name, func(t *testing.T) {
+			indexStructure := newDuplicatesIndex(t, layout.postingLists, "apple", 3)
+			Insert("apply", 9, indexStructure)
+			if SetPostingLists(!layout.postingLists, indexStructure) {
+				t.Fatal("SetPostingLists succeeded on an index holding keys")
+			}
+			if results := selectSorted("apple", indexStructure); !slices.Equal(results, []int{0, 1, 2}) {
+				t.Errorf("after the failed switch Select returned %v", results)
+			}
+			if err := Verify(indexStructure); err != nil {
+				t.Errorf("after the failed switch: %v", err)
+			}
+			// once every key is gone the layout can be changed
+			for keyElement := range 3 {
+				Delete("apple", keyElement, indexStructure)
+			}
+			Delete("apply", 9, indexStructure)
+			if !SetPostingLists(!layout.postingLists, indexStructure) {
+				t.Fatal("SetPostingLists failed once the index was emptied")
+			}
+			for _, keyElement := range []int{12, 3, 123} {
+				Insert("apple", keyElement, indexStructure)
+			}
+			if results := selectSorted("apple", indexStructure); !slices.Equal(results, []int{3, 12, 123}) {
+				t.Errorf("after the switch Select returned %v", results)
+			}
+			if err := Verify(indexStructure); err != nil {
+				t.Errorf("after the switch: %v", err)
+			}
+		})
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestDeleteLastButOneDuplicate(t *testing.T) {
+	tests := []struct {
+		name      string
+		keyString string // "abc" is a 'terminal' node -- "ab" is followed by "abc"
+		elements  []int
+		deleted   int
+	}{
+		{"terminal -- last inserted", "abc", []int{7, 42}, 42},
+		{"terminal -- first inserted", "abc", []int{7, 42}, 7},
+		{"terminal -- one a prefix of the other", "abc", []int{12, 123}, 12},
+		{"terminal -- other a prefix of the one", "abc", []int{12, 123}, 123},
+		{"key -- last inserted", "ab", []int{7, 42}, 42},
+		{"key -- one a prefix of the other", "ab", []int{12, 123}, 12},
+	}
+	for _, layout := range duplicateLayouts {
+		for _, test := range tests {
+			t.Run(layout.name+"/"+test.name, func(t *testing.T) {
+				indexStructure := newDuplicatesIndex(t, layout.postingLists, "abc", 0)
+				if test.keyString != "abc" { // something after the key
+					Insert("abc", 1, indexStructure)
+				}
+				for _, keyElement := range test.elements {
+					Insert(test.keyString, keyElement, indexStructure)
+				}
+				if !Delete(test.keyString, test.deleted, indexStructure) {
+					t.Fatal("Delete failed")
+				}
+				remaining := slices.DeleteFunc(slices.Clone(test.elements), func(keyElement int) bool {
+					return keyElement == test.deleted
+				})
+				if results := selectSorted(test.keyString, indexStructure); !slices.Equal(results, remaining) {
+					t.Errorf("Select returned %v, want %v", results, remaining)
+				}
+				if err := Verify(indexStructure); err != nil {
+					t.Error(err)
+				}
+				if result, _ := Statistics(indexStructure); result.DuplicateKeyNodeCount+
+					result.DuplicateTerminalNodeCount != 0 {
+					t.Errorf("'duplicate' nodes left for one element -- %+v", result)
+				}
+				if Delete(test.keyString, test.deleted, indexStructure) {
+					t.Error("the deleted element was deleted again")
+				}
+				// the key goes with its last element -- leaving any other key as it was
+				if !Delete(test.keyString, remaining[0], indexStructure) {
+					t.Fatal("Delete of the last element failed")
+				}
+				if success, _ := Select(test.keyString, indexStructure); success {
+					t.Error("the key was still found")
+				}
+				if test.keyString != "abc" && !slices.Equal(selectSorted("abc", indexStructure), []int{1}) {
+					t.Error("the other key was lost")
+				}
+				if err := Verify(indexStructure); err != nil {
+					t.Error(err)
+				}
+			})
+		}
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestPostingListBlocks(t *testing.T) {
+	// enough elements for several blocks are inserted then most deleted again in different orders -- a snapshot
+	// taken half way must keep the elements it was taken with however its lists are changed afterwards
+	const elementCount = 5 * postingBlockLength
+	ascending := make([]int, elementCount)
+	for i := range ascending {
+		ascending[i] = i
+	}
+	descending := slices.Clone(ascending)
+	slices.Reverse(descending)
+	scattered := make([]int, elementCount)
+	for i := range scattered {
+		scattered[i] = i * 7919 % elementCount
+	}
+	tests := []struct {
+		name                  string
+		inserted, deleted     []int
+		snapshotAfterInserted int
+	}{
+		{"ascending", ascending, ascending[:elementCount-3], elementCount / 2},
+		{"descending", descending, ascending[3:], elementCount / 2},
+		{"scattered", scattered, scattered[:elementCount-3], elementCount / 3},
+		{"inserted ascending deleted descending", ascending, descending[:elementCount-3], elementCount - 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			indexStructure := newDuplicatesIndex(t, true, "status", 0)
+			var snapshot *Index
+			var snapshotElements []int
+			for i, keyElement := range test.inserted {
+				if !Insert("status", keyElement, indexStructure) {
+					t.Fatalf("Insert of %d failed", keyElement)
+				}
+				if i == test.snapshotAfterInserted {
+					snapshot = Snapshot(indexStructure)
+					snapshotElements = selectSorted("status", snapshot)
+				}
+			}
+			if err := Verify(indexStructure); err != nil {
+				t.Fatal(err)
+			}
+			if results := selectSorted("status", indexStructure); !slices.Equal(results, ascending) {
+				t.Fatalf("holds %d elements, want %d", len(results), elementCount)
+			}
+			for _, keyElement := range test.deleted {
+				if !Delete("status", keyElement, indexStructure) {
+					t.Fatalf("Delete of %d failed", keyElement)
+				}
+			}
+			if err := Verify(indexStructure); err != nil {
+				t.Fatal(err)
+			}
+			remaining := slices.DeleteFunc(slices.Clone(ascending), func(keyElement int) bool {
+				return slices.Contains(test.deleted, keyElement)
+			})
+			if results := selectSorted("status", indexStructure); !slices.Equal(results, remaining) {
+				t.Errorf("holds %v, want %v", results, remaining)
+			}
+			if results := selectSorted("status", snapshot); !slices.Equal(results, snapshotElements) ||
+				len(results) != test.snapshotAfterInserted+1 {
+				t.Errorf("the snapshot changed -- holds %d elements, want %d", len(results),
+					test.snapshotAfterInserted+1)
+			}
+			if err := Verify(snapshot); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		keyInput  string
+		collation Collation
+		keyString string
+	}{
+		{"short", " apple ", nil, "apple"},
+		{"exactly the longest", strings.Repeat("a", maxKeyLength), nil, strings.Repeat("a", maxKeyLength)},
+		{"cut", strings.Repeat("a", maxKeyLength+1), nil, strings.Repeat("a", maxKeyLength)},
+		{"two-byte characters cut between", strings.Repeat("é", 20), nil, strings.Repeat("é", 16)},
+		{"two-byte character cut through", "a" + strings.Repeat("é", 20), nil, "a" + strings.Repeat("é", 15)},
+		{"three-byte character cut through", strings.Repeat("日", 11), nil, strings.Repeat("日", 10)},
+		{"four-byte character cut through", "a" + strings.Repeat("😀", 8), nil, "a" + strings.Repeat("😀", 7)},
+		{"collated", "Apple", strings.ToLower, "apple"},
+		{"collated then cut", strings.Repeat("A", 40), strings.ToLower, strings.Repeat("a", maxKeyLength)},
+		{"empty", "  ", strings.ToUpper, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			keyString, keyLength := validate(test.keyInput, test.collation)
+			if keyString != test.keyString || keyLength != len(test.keyString) {
+				t.Errorf("got %q %d, want %q", keyString, keyLength, test.keyString)
+			}
+			if test.collation == nil && !utf8.ValidString(keyString) {
+				t.Errorf("%q is not valid UTF-8", keyString)
+			}
+		})
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestSetCollation(t *testing.T) {
+	var indexStructure Index
+	Initialise(&indexStructure)
+	if !SetCollation(strings.ToLower, &indexStructure) {
+		t.Fatal("SetCollation failed on an empty index")
+	}
+	Insert("Apple", 1, &indexStructure)
+	Insert("APPLY", 2, &indexStructure)
+	Insert("banana", 3, &indexStructure)
+	if SetCollation(nil, &indexStructure) {
+		t.Fatal("SetCollation succeeded on an index holding keys")
+	}
+	tests := []struct {
+		name    string
+		call    func() (bool, []int)
+		results []int
+	}{
+		{"select", func() (bool, []int) { return Select("aPPLE", &indexStructure) }, []int{1}},
+		{"search", func() (bool, []int) { return Search("APP", &indexStructure) }, []int{1, 2}},
+		{"range", func() (bool, []int) { return Range("APPLZ", "Banana", &indexStructure) }, []int{3}},
+		{"delete", func() (bool, []int) { return Delete("BANANA", 3, &indexStructure), []int{3} }, []int{3}},
+		{"deleted", func() (bool, []int) { return Select("banana", &indexStructure) }, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			success, results := test.call()
+			if success != (len(test.results) > 0) || !slices.Equal(results, test.results) {
+				t.Errorf("got %v %v, want %v", success, results, test.results)
+			}
+		})
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestContextCancelledWhileLocked(t *testing.T) {
+	// the index is held locked (as by a long call) -- a call whose context is cancelled while it waits must give up
+	// with ctx.Err() and leave the lock alone so the index carries on once it is unlocked
+	calls := map[string]func(ctx context.Context, indexStructure *Index) (bool, []int, error){
+		"ScanContext": ScanContext,
+		"SearchContext": func(ctx context.Context, indexStructure *Index) (bool, []int, error) {
+			return SearchContext(ctx, "app", indexStructure)
+		},
+	}
+	tests := []struct {
+		name    string
+		context func() (context.Context, context.CancelFunc)
+		err     error
+	}{
+		{"timed out", func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 20*time.Millisecond)
+		}, context.DeadlineExceeded},
+		{"cancelled while waiting", func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(20*time.Millisecond, cancel)
+			return ctx, cancel
+		}, context.Canceled},
+		{"cancelled before", func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			return ctx, cancel
+		}, context.Canceled},
+	}
+	for _, name := range []string{"ScanContext", "SearchContext"} {
+		for _, test := range tests {
+			t.Run(name+"/"+test.name, func(t *testing.T) {
+				indexStructure := newDuplicatesIndex(t, false, "apple", 2)
+				indexStructure.indexMutex.Lock()
+				ctx, cancel := test.context()
+				defer cancel()
+				returned := make(chan error, 1)
+				go func() {
+					success, results, err := calls[name](ctx, indexStructure)
+					if success || results != nil {
+						t.Errorf("returned %v %v", success, results)
+					}
+					returned <- err
+				}()
+				select {
+				case err := <-returned:
+					if !errors.Is(err, test.err) {
+						t.Errorf("got %v, want %v", err, test.err)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatal("still waiting for the lock after the context was cancelled")
+				}
+				indexStructure.indexMutex.Unlock()
+				if !Insert("apply", 3, indexStructure) { // the abandoned wait must have given the lock back
+					t.Error("Insert failed after the lock was released")
+				}
+				success, results, err := calls[name](context.Background(), indexStructure)
+				if !success || len(results) != 3 || err != nil {
+					t.Errorf("once unlocked returned %v %v %v", success, results, err)
+				}
+			})
+		}
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestEntries(t *testing.T) {
+	// more keys than are found each time the index is locked -- the loop changes the index as it goes (which would
+	// deadlock if the index were held locked) and must see every key that was there before it, once and in order
+	// -- without the index being left sharing its node array, so the changes are made in place
+	indexStructure := newDuplicatesIndex(t, true, "key0000", 2)
+	for i := 1; i < 3*entriesPerLock+10; i++ {
+		Insert("key"+strconv.Itoa(10000 + i)[1:], i, indexStructure)
+	}
+	want := allEntries(indexStructure)
+	var got []KeyEntry
+	for entry := range Entries("", indexStructure) {
+		got = append(got, entry)
+		Insert("zzz", len(got), indexStructure)
+		if indexStructure.nodeShared {
+			t.Fatal("the node array is shared while Entries is in progress")
+		}
+	}
+	if got = got[:min(len(got), len(want))]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %d entries, want %d", len(got), len(want))
+	}
+	var afterEntries []KeyEntry
+	for entry := range Entries(want[entriesPerLock].Key, indexStructure) {
+		if afterEntries = append(afterEntries, entry); len(afterEntries) == 3 {
+			break
+		}
+	}
+	if !reflect.DeepEqual(afterEntries, want[entriesPerLock+1:entriesPerLock+4]) {
+		t.Errorf("after %q got %v", want[entriesPerLock].Key, afterEntries)
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// BenchmarkDuplicates compares the two layouts of the elements of a key holding thousands of duplicates -- see
+// SetPostingLists
+//
+func BenchmarkDuplicates(b *testing.B) {
+	const duplicateCount = 5000
+	for _, layout := range duplicateLayouts {
+		b.Run(layout.name+"/Insert", func(b *testing.B) {
+			indexStructure := newDuplicatesIndex(b, layout.postingLists, "duplicated", duplicateCount)
+			b.ResetTimer()
+			for i := range b.N {
+				Insert("duplicated", duplicateCount+i, indexStructure)
+			}
+		})
+		b.Run(layout.name+"/Select", func(b *testing.B) {
+			indexStructure := newDuplicatesIndex(b, layout.postingLists, "duplicated", duplicateCount)
+			b.ResetTimer()
+			for range b.N {
+				Select("duplicated", indexStructure)
+			}
+		})
+		b.Run(layout.name+"/Delete", func(b *testing.B) {
+			// half the elements are deleted (spread over the key) then the key is filled up again untimed -- so it
+			// holds between one and two times 'duplicateCount' throughout
+			indexStructure := newDuplicatesIndex(b, layout.postingLists, "duplicated", 0)
+			b.ResetTimer()
+			for i := range b.N {
+				if i%duplicateCount == 0 {
+					b.StopTimer()
+					for keyElement := range 2 * duplicateCount {
+						Insert("duplicated", keyElement, indexStructure)
+					}
+					b.StartTimer()
+				}
+				Delete("duplicated", i*7919%(2*duplicateCount), indexStructure)
+			}
+		})
+	}
+}
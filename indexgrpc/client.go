@@ -0,0 +1,231 @@
+package indexgrpc
+
+import (
+	"context"
+	"io"
+	"iter"
+
+	"google.golang.org/grpc"
+
+	"github.com/apwoodhouse/index"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Client calls the IndexService over a gRPC connection
+//
+type Client struct {
+	serviceClient IndexServiceClient
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func ints(wireNumbers []int64) (numbers []int) {
+	if len(wireNumbers) == 0 { // nothing rather than an empty slice -- as the index returns
+		return
+	}
+	numbers = make([]int, len(wireNumbers))
+	for i, wireNumber := range wireNumbers {
+		numbers[i] = int(wireNumber)
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func elementsResult(response *ElementsResponse, err error) (success bool, results []int, callErr error) {
+	// the results of a call returning ElementsResponse -- nothing if it failed
+	success, results, callErr = response.GetSuccess(), ints(response.GetElements()), err
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// NewClient returns a Client using a connection made by grpc.NewClient (or anything else that can make calls)
+//
+func NewClient(clientConnection grpc.ClientConnInterface) *Client {
+	return &Client{serviceClient: NewIndexServiceClient(clientConnection)}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Insert calls index.Insert on the server
+//
+func (client *Client) Insert(ctx context.Context, keyInput string, keyElement int) (success bool, err error) {
+	response, err := client.serviceClient.Insert(ctx, &ChangeRequest{Key: keyInput, Element: int64(keyElement)})
+	success = response.GetSuccess()
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Delete calls index.Delete on the server
+//
+func (client *Client) Delete(ctx context.Context, keyInput string, keyElement int) (success bool, err error) {
+	response, err := client.serviceClient.Delete(ctx, &ChangeRequest{Key: keyInput, Element: int64(keyElement)})
+	success = response.GetSuccess()
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Select calls index.Select on the server
+//
+func (client *Client) Select(ctx context.Context, keyInput string) (success bool, results []int, err error) {
+	return elementsResult(client.serviceClient.Select(ctx, &KeyRequest{Key: keyInput}))
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Search calls index.Search on the server
+//
+func (client *Client) Search(ctx context.Context, keyInput string) (success bool, results []int, err error) {
+	return elementsResult(client.serviceClient.Search(ctx, &KeyRequest{Key: keyInput}))
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Range calls index.Range on the server
+//
+func (client *Client) Range(ctx context.Context, fromInput, toInput string) (success bool, results []int,
+	err error) {
+	return elementsResult(client.serviceClient.Range(ctx, &RangeRequest{From: fromInput, To: toInput}))
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Stats returns index.Statistics for the index on the server
+//
+func (client *Client) Stats(ctx context.Context) (result index.Statistic, err error) {
+	response, err := client.serviceClient.Stats(ctx, &StatsRequest{})
+	if err != nil {
+		return
+	}
+	result = index.Statistic{
+		Active:                     int(response.Active),
+		Deleted:                    int(response.Deleted),
+		Depth:                      int(response.Depth),
+		DecisionNodeCount:          int(response.DecisionNodes),
+		CharacterNodeCount:         int(response.CharacterNodes),
+		IndexKeyNodeCount:          int(response.IndexKeyNodes),
+		IndexTerminalNodeCount:     int(response.IndexTerminalNodes),
+		DuplicateKeyNodeCount:      int(response.DuplicateKeyNodes),
+		DuplicateTerminalNodeCount: int(response.DuplicateTerminalNodes),
+		NodeBytes:                  int(response.NodeBytes),
+		DeletedRatio:               response.DeletedRatio,
+		KeyLengths:                 ints(response.KeyLengths),
+		BranchingFactors:           ints(response.BranchingFactors),
+		LargestDuplicateCount:      int(response.LargestDuplicateCount),
+		LargestDuplicateKey:        response.LargestDuplicateKey,
+		AveragePathLength:          response.AveragePathLength,
+		MaximumPathLength:          int(response.MaximumPathLength),
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Scan returns an iterator over the keys on the server after 'afterKey' (a key as stored, as for index.ScanKeys --
+//      empty for every key) and their Element numbers in key order -- as the server sends them
+//      a failure ends the loop with a non-nil error -- stopping the loop early ends the call
+//
+func (client *Client) Scan(ctx context.Context, afterKey string) iter.Seq2[index.KeyEntry, error] {
+	return func(yield func(index.KeyEntry, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		//
+		stream, err := client.serviceClient.Scan(ctx, &ScanRequest{After: []byte(afterKey)})
+		if err != nil {
+			yield(index.KeyEntry{}, err)
+			return
+		}
+		for {
+			message, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF { // EOF is the end of the keys
+					yield(index.KeyEntry{}, err)
+				}
+				return
+			}
+			if !yield(index.KeyEntry{Key: string(message.Key), Elements: ints(message.Elements)}, nil) {
+				return
+			}
+		}
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Watch returns an iterator over the changes made to the index on the server, as index.Subscribe -- every change
+//       made once the loop has started, until it stops or 'ctx' is done
+//       a server that drops the watch (it fell too far behind, or the content was replaced) ends the loop with a
+//       ResourceExhausted error
+//
+func (client *Client) Watch(ctx context.Context) iter.Seq2[index.Event, error] {
+	return func(yield func(index.Event, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		//
+		stream, err := client.serviceClient.Watch(ctx, &WatchRequest{})
+		if err == nil {
+			_, err = stream.Header() // the server is subscribed once it has sent the header
+		}
+		if err != nil {
+			yield(index.Event{}, err)
+			return
+		}
+		for {
+			message, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					yield(index.Event{}, err)
+				}
+				return
+			}
+			event := index.Event{Op: index.EventInsert, Key: string(message.Key), Element: int(message.Element),
+				Seq: message.Seq}
+			if message.Op == WatchEvent_OP_DELETE {
+				event.Op = index.EventDelete
+			}
+			if !yield(event, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,872 @@
+// IndexService gives remote access to an index -- see the indexgrpc package for the Go server and client
+//
+// keys sent back (ScanEntry and WatchEvent) are as stored in the index -- after any collation -- so they are bytes
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: index.proto
+
+package indexgrpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type WatchEvent_Op int32
+
+const (
+	WatchEvent_OP_UNSPECIFIED WatchEvent_Op = 0
+	WatchEvent_OP_INSERT      WatchEvent_Op = 1
+	WatchEvent_OP_DELETE      WatchEvent_Op = 2
+)
+
+// Enum value maps for WatchEvent_Op.
+var (
+	WatchEvent_Op_name = map[int32]string{
+		0: "OP_UNSPECIFIED",
+		1: "OP_INSERT",
+		2: "OP_DELETE",
+	}
+	WatchEvent_Op_value = map[string]int32{
+		"OP_UNSPECIFIED": 0,
+		"OP_INSERT":      1,
+		"OP_DELETE":      2,
+	}
+)
+
+func (x WatchEvent_Op) Enum() *WatchEvent_Op {
+	p := new(WatchEvent_Op)
+	*p = x
+	return p
+}
+
+func (x WatchEvent_Op) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (WatchEvent_Op) Descriptor() protoreflect.EnumDescriptor {
+	return file_index_proto_enumTypes[0].Descriptor()
+}
+
+func (WatchEvent_Op) Type() protoreflect.EnumType {
+	return &file_index_proto_enumTypes[0]
+}
+
+func (x WatchEvent_Op) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use WatchEvent_Op.Descriptor instead.
+func (WatchEvent_Op) EnumDescriptor() ([]byte, []int) {
+	return file_index_proto_rawDescGZIP(), []int{10, 0}
+}
+
+type ChangeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Element       int64                  `protobuf:"varint,2,opt,name=element,proto3" json:"element,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangeRequest) Reset() {
+	*x = ChangeRequest{}
+	mi := &file_index_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangeRequest) ProtoMessage() {}
+
+func (x *ChangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_index_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangeRequest.ProtoReflect.Descriptor instead.
+func (*ChangeRequest) Descriptor() ([]byte, []int) {
+	return file_index_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ChangeRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ChangeRequest) GetElement() int64 {
+	if x != nil {
+		return x.Element
+	}
+	return 0
+}
+
+type ChangeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangeResponse) Reset() {
+	*x = ChangeResponse{}
+	mi := &file_index_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangeResponse) ProtoMessage() {}
+
+func (x *ChangeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_index_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangeResponse.ProtoReflect.Descriptor instead.
+func (*ChangeResponse) Descriptor() ([]byte, []int) {
+	return file_index_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ChangeResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+type KeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyRequest) Reset() {
+	*x = KeyRequest{}
+	mi := &file_index_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyRequest) ProtoMessage() {}
+
+func (x *KeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_index_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyRequest.ProtoReflect.Descriptor instead.
+func (*KeyRequest) Descriptor() ([]byte, []int) {
+	return file_index_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *KeyRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type RangeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"` // empty for the first key
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`     // empty for the last key
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RangeRequest) Reset() {
+	*x = RangeRequest{}
+	mi := &file_index_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RangeRequest) ProtoMessage() {}
+
+func (x *RangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_index_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RangeRequest.ProtoReflect.Descriptor instead.
+func (*RangeRequest) Descriptor() ([]byte, []int) {
+	return file_index_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RangeRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *RangeRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+type ElementsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Elements      []int64                `protobuf:"varint,2,rep,packed,name=elements,proto3" json:"elements,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ElementsResponse) Reset() {
+	*x = ElementsResponse{}
+	mi := &file_index_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ElementsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ElementsResponse) ProtoMessage() {}
+
+func (x *ElementsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_index_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ElementsResponse.ProtoReflect.Descriptor instead.
+func (*ElementsResponse) Descriptor() ([]byte, []int) {
+	return file_index_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ElementsResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ElementsResponse) GetElements() []int64 {
+	if x != nil {
+		return x.Elements
+	}
+	return nil
+}
+
+type ScanRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	After         []byte                 `protobuf:"bytes,1,opt,name=after,proto3" json:"after,omitempty"` // the key of the last entry already received -- empty to start at the beginning
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanRequest) Reset() {
+	*x = ScanRequest{}
+	mi := &file_index_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanRequest) ProtoMessage() {}
+
+func (x *ScanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_index_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanRequest.ProtoReflect.Descriptor instead.
+func (*ScanRequest) Descriptor() ([]byte, []int) {
+	return file_index_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ScanRequest) GetAfter() []byte {
+	if x != nil {
+		return x.After
+	}
+	return nil
+}
+
+type ScanEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           []byte                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Elements      []int64                `protobuf:"varint,2,rep,packed,name=elements,proto3" json:"elements,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScanEntry) Reset() {
+	*x = ScanEntry{}
+	mi := &file_index_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScanEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScanEntry) ProtoMessage() {}
+
+func (x *ScanEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_index_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScanEntry.ProtoReflect.Descriptor instead.
+func (*ScanEntry) Descriptor() ([]byte, []int) {
+	return file_index_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ScanEntry) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *ScanEntry) GetElements() []int64 {
+	if x != nil {
+		return x.Elements
+	}
+	return nil
+}
+
+type StatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatsRequest) Reset() {
+	*x = StatsRequest{}
+	mi := &file_index_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsRequest) ProtoMessage() {}
+
+func (x *StatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_index_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return file_index_proto_rawDescGZIP(), []int{7}
+}
+
+type StatsResponse struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	Active                 int64                  `protobuf:"varint,1,opt,name=active,proto3" json:"active,omitempty"`
+	Deleted                int64                  `protobuf:"varint,2,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	Depth                  int64                  `protobuf:"varint,3,opt,name=depth,proto3" json:"depth,omitempty"`
+	DecisionNodes          int64                  `protobuf:"varint,4,opt,name=decision_nodes,json=decisionNodes,proto3" json:"decision_nodes,omitempty"`
+	CharacterNodes         int64                  `protobuf:"varint,5,opt,name=character_nodes,json=characterNodes,proto3" json:"character_nodes,omitempty"`
+	IndexKeyNodes          int64                  `protobuf:"varint,6,opt,name=index_key_nodes,json=indexKeyNodes,proto3" json:"index_key_nodes,omitempty"`
+	IndexTerminalNodes     int64                  `protobuf:"varint,7,opt,name=index_terminal_nodes,json=indexTerminalNodes,proto3" json:"index_terminal_nodes,omitempty"`
+	DuplicateKeyNodes      int64                  `protobuf:"varint,8,opt,name=duplicate_key_nodes,json=duplicateKeyNodes,proto3" json:"duplicate_key_nodes,omitempty"`
+	DuplicateTerminalNodes int64                  `protobuf:"varint,9,opt,name=duplicate_terminal_nodes,json=duplicateTerminalNodes,proto3" json:"duplicate_terminal_nodes,omitempty"`
+	NodeBytes              int64                  `protobuf:"varint,10,opt,name=node_bytes,json=nodeBytes,proto3" json:"node_bytes,omitempty"`
+	DeletedRatio           float64                `protobuf:"fixed64,11,opt,name=deleted_ratio,json=deletedRatio,proto3" json:"deleted_ratio,omitempty"`
+	KeyLengths             []int64                `protobuf:"varint,12,rep,packed,name=key_lengths,json=keyLengths,proto3" json:"key_lengths,omitempty"`                   // subscripted by key length
+	BranchingFactors       []int64                `protobuf:"varint,13,rep,packed,name=branching_factors,json=branchingFactors,proto3" json:"branching_factors,omitempty"` // subscripted by the number of next characters
+	LargestDuplicateCount  int64                  `protobuf:"varint,14,opt,name=largest_duplicate_count,json=largestDuplicateCount,proto3" json:"largest_duplicate_count,omitempty"`
+	LargestDuplicateKey    []byte                 `protobuf:"bytes,15,opt,name=largest_duplicate_key,json=largestDuplicateKey,proto3" json:"largest_duplicate_key,omitempty"`
+	AveragePathLength      float64                `protobuf:"fixed64,16,opt,name=average_path_length,json=averagePathLength,proto3" json:"average_path_length,omitempty"`
+	MaximumPathLength      int64                  `protobuf:"varint,17,opt,name=maximum_path_length,json=maximumPathLength,proto3" json:"maximum_path_length,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *StatsResponse) Reset() {
+	*x = StatsResponse{}
+	mi := &file_index_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsResponse) ProtoMessage() {}
+
+func (x *StatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_index_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsResponse.ProtoReflect.Descriptor instead.
+func (*StatsResponse) Descriptor() ([]byte, []int) {
+	return file_index_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *StatsResponse) GetActive() int64 {
+	if x != nil {
+		return x.Active
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetDeleted() int64 {
+	if x != nil {
+		return x.Deleted
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetDepth() int64 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetDecisionNodes() int64 {
+	if x != nil {
+		return x.DecisionNodes
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetCharacterNodes() int64 {
+	if x != nil {
+		return x.CharacterNodes
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetIndexKeyNodes() int64 {
+	if x != nil {
+		return x.IndexKeyNodes
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetIndexTerminalNodes() int64 {
+	if x != nil {
+		return x.IndexTerminalNodes
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetDuplicateKeyNodes() int64 {
+	if x != nil {
+		return x.DuplicateKeyNodes
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetDuplicateTerminalNodes() int64 {
+	if x != nil {
+		return x.DuplicateTerminalNodes
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetNodeBytes() int64 {
+	if x != nil {
+		return x.NodeBytes
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetDeletedRatio() float64 {
+	if x != nil {
+		return x.DeletedRatio
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetKeyLengths() []int64 {
+	if x != nil {
+		return x.KeyLengths
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetBranchingFactors() []int64 {
+	if x != nil {
+		return x.BranchingFactors
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetLargestDuplicateCount() int64 {
+	if x != nil {
+		return x.LargestDuplicateCount
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetLargestDuplicateKey() []byte {
+	if x != nil {
+		return x.LargestDuplicateKey
+	}
+	return nil
+}
+
+func (x *StatsResponse) GetAveragePathLength() float64 {
+	if x != nil {
+		return x.AveragePathLength
+	}
+	return 0
+}
+
+func (x *StatsResponse) GetMaximumPathLength() int64 {
+	if x != nil {
+		return x.MaximumPathLength
+	}
+	return 0
+}
+
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_index_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_index_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_index_proto_rawDescGZIP(), []int{9}
+}
+
+type WatchEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Op            WatchEvent_Op          `protobuf:"varint,1,opt,name=op,proto3,enum=index.v1.WatchEvent_Op" json:"op,omitempty"`
+	Key           []byte                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Element       int64                  `protobuf:"varint,3,opt,name=element,proto3" json:"element,omitempty"`
+	Seq           uint64                 `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchEvent) Reset() {
+	*x = WatchEvent{}
+	mi := &file_index_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchEvent) ProtoMessage() {}
+
+func (x *WatchEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_index_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchEvent.ProtoReflect.Descriptor instead.
+func (*WatchEvent) Descriptor() ([]byte, []int) {
+	return file_index_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *WatchEvent) GetOp() WatchEvent_Op {
+	if x != nil {
+		return x.Op
+	}
+	return WatchEvent_OP_UNSPECIFIED
+}
+
+func (x *WatchEvent) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *WatchEvent) GetElement() int64 {
+	if x != nil {
+		return x.Element
+	}
+	return 0
+}
+
+func (x *WatchEvent) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+var File_index_proto protoreflect.FileDescriptor
+
+const file_index_proto_rawDesc = "" +
+	"\n" +
+	"\vindex.proto\x12\bindex.v1\";\n" +
+	"\rChangeRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x18\n" +
+	"\aelement\x18\x02 \x01(\x03R\aelement\"*\n" +
+	"\x0eChangeResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"\x1e\n" +
+	"\n" +
+	"KeyRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"2\n" +
+	"\fRangeRequest\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\"H\n" +
+	"\x10ElementsResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x1a\n" +
+	"\belements\x18\x02 \x03(\x03R\belements\"#\n" +
+	"\vScanRequest\x12\x14\n" +
+	"\x05after\x18\x01 \x01(\fR\x05after\"9\n" +
+	"\tScanEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\fR\x03key\x12\x1a\n" +
+	"\belements\x18\x02 \x03(\x03R\belements\"\x0e\n" +
+	"\fStatsRequest\"\xc9\x05\n" +
+	"\rStatsResponse\x12\x16\n" +
+	"\x06active\x18\x01 \x01(\x03R\x06active\x12\x18\n" +
+	"\adeleted\x18\x02 \x01(\x03R\adeleted\x12\x14\n" +
+	"\x05depth\x18\x03 \x01(\x03R\x05depth\x12%\n" +
+	"\x0edecision_nodes\x18\x04 \x01(\x03R\rdecisionNodes\x12'\n" +
+	"\x0fcharacter_nodes\x18\x05 \x01(\x03R\x0echaracterNodes\x12&\n" +
+	"\x0findex_key_nodes\x18\x06 \x01(\x03R\rindexKeyNodes\x120\n" +
+	"\x14index_terminal_nodes\x18\a \x01(\x03R\x12indexTerminalNodes\x12.\n" +
+	"\x13duplicate_key_nodes\x18\b \x01(\x03R\x11duplicateKeyNodes\x128\n" +
+	"\x18duplicate_terminal_nodes\x18\t \x01(\x03R\x16duplicateTerminalNodes\x12\x1d\n" +
+	"\n" +
+	"node_bytes\x18\n" +
+	" \x01(\x03R\tnodeBytes\x12#\n" +
+	"\rdeleted_ratio\x18\v \x01(\x01R\fdeletedRatio\x12\x1f\n" +
+	"\vkey_lengths\x18\f \x03(\x03R\n" +
+	"keyLengths\x12+\n" +
+	"\x11branching_factors\x18\r \x03(\x03R\x10branchingFactors\x126\n" +
+	"\x17largest_duplicate_count\x18\x0e \x01(\x03R\x15largestDuplicateCount\x122\n" +
+	"\x15largest_duplicate_key\x18\x0f \x01(\fR\x13largestDuplicateKey\x12.\n" +
+	"\x13average_path_length\x18\x10 \x01(\x01R\x11averagePathLength\x12.\n" +
+	"\x13maximum_path_length\x18\x11 \x01(\x03R\x11maximumPathLength\"\x0e\n" +
+	"\fWatchRequest\"\xab\x01\n" +
+	"\n" +
+	"WatchEvent\x12'\n" +
+	"\x02op\x18\x01 \x01(\x0e2\x17.index.v1.WatchEvent.OpR\x02op\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\fR\x03key\x12\x18\n" +
+	"\aelement\x18\x03 \x01(\x03R\aelement\x12\x10\n" +
+	"\x03seq\x18\x04 \x01(\x04R\x03seq\"6\n" +
+	"\x02Op\x12\x12\n" +
+	"\x0eOP_UNSPECIFIED\x10\x00\x12\r\n" +
+	"\tOP_INSERT\x10\x01\x12\r\n" +
+	"\tOP_DELETE\x10\x022\xe6\x03\n" +
+	"\fIndexService\x12;\n" +
+	"\x06Insert\x12\x17.index.v1.ChangeRequest\x1a\x18.index.v1.ChangeResponse\x12;\n" +
+	"\x06Delete\x12\x17.index.v1.ChangeRequest\x1a\x18.index.v1.ChangeResponse\x12:\n" +
+	"\x06Select\x12\x14.index.v1.KeyRequest\x1a\x1a.index.v1.ElementsResponse\x12:\n" +
+	"\x06Search\x12\x14.index.v1.KeyRequest\x1a\x1a.index.v1.ElementsResponse\x12;\n" +
+	"\x05Range\x12\x16.index.v1.RangeRequest\x1a\x1a.index.v1.ElementsResponse\x124\n" +
+	"\x04Scan\x12\x15.index.v1.ScanRequest\x1a\x13.index.v1.ScanEntry0\x01\x128\n" +
+	"\x05Stats\x12\x16.index.v1.StatsRequest\x1a\x17.index.v1.StatsResponse\x127\n" +
+	"\x05Watch\x12\x16.index.v1.WatchRequest\x1a\x14.index.v1.WatchEvent0\x01B(Z&github.com/apwoodhouse/index/indexgrpcb\x06proto3"
+
+var (
+	file_index_proto_rawDescOnce sync.Once
+	file_index_proto_rawDescData []byte
+)
+
+func file_index_proto_rawDescGZIP() []byte {
+	file_index_proto_rawDescOnce.Do(func() {
+		file_index_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_index_proto_rawDesc), len(file_index_proto_rawDesc)))
+	})
+	return file_index_proto_rawDescData
+}
+
+var file_index_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_index_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_index_proto_goTypes = []any{
+	(WatchEvent_Op)(0),       // 0: index.v1.WatchEvent.Op
+	(*ChangeRequest)(nil),    // 1: index.v1.ChangeRequest
+	(*ChangeResponse)(nil),   // 2: index.v1.ChangeResponse
+	(*KeyRequest)(nil),       // 3: index.v1.KeyRequest
+	(*RangeRequest)(nil),     // 4: index.v1.RangeRequest
+	(*ElementsResponse)(nil), // 5: index.v1.ElementsResponse
+	(*ScanRequest)(nil),      // 6: index.v1.ScanRequest
+	(*ScanEntry)(nil),        // 7: index.v1.ScanEntry
+	(*StatsRequest)(nil),     // 8: index.v1.StatsRequest
+	(*StatsResponse)(nil),    // 9: index.v1.StatsResponse
+	(*WatchRequest)(nil),     // 10: index.v1.WatchRequest
+	(*WatchEvent)(nil),       // 11: index.v1.WatchEvent
+}
+var file_index_proto_depIdxs = []int32{
+	0,  // 0: index.v1.WatchEvent.op:type_name -> index.v1.WatchEvent.Op
+	1,  // 1: index.v1.IndexService.Insert:input_type -> index.v1.ChangeRequest
+	1,  // 2: index.v1.IndexService.Delete:input_type -> index.v1.ChangeRequest
+	3,  // 3: index.v1.IndexService.Select:input_type -> index.v1.KeyRequest
+	3,  // 4: index.v1.IndexService.Search:input_type -> index.v1.KeyRequest
+	4,  // 5: index.v1.IndexService.Range:input_type -> index.v1.RangeRequest
+	6,  // 6: index.v1.IndexService.Scan:input_type -> index.v1.ScanRequest
+	8,  // 7: index.v1.IndexService.Stats:input_type -> index.v1.StatsRequest
+	10, // 8: index.v1.IndexService.Watch:input_type -> index.v1.WatchRequest
+	2,  // 9: index.v1.IndexService.Insert:output_type -> index.v1.ChangeResponse
+	2,  // 10: index.v1.IndexService.Delete:output_type -> index.v1.ChangeResponse
+	5,  // 11: index.v1.IndexService.Select:output_type -> index.v1.ElementsResponse
+	5,  // 12: index.v1.IndexService.Search:output_type -> index.v1.ElementsResponse
+	5,  // 13: index.v1.IndexService.Range:output_type -> index.v1.ElementsResponse
+	7,  // 14: index.v1.IndexService.Scan:output_type -> index.v1.ScanEntry
+	9,  // 15: index.v1.IndexService.Stats:output_type -> index.v1.StatsResponse
+	11, // 16: index.v1.IndexService.Watch:output_type -> index.v1.WatchEvent
+	9,  // [9:17] is the sub-list for method output_type
+	1,  // [1:9] is the sub-list for method input_type
+	1,  // [1:1] is the sub-list for extension type_name
+	1,  // [1:1] is the sub-list for extension extendee
+	0,  // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_index_proto_init() }
+func file_index_proto_init() {
+	if File_index_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_index_proto_rawDesc), len(file_index_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_index_proto_goTypes,
+		DependencyIndexes: file_index_proto_depIdxs,
+		EnumInfos:         file_index_proto_enumTypes,
+		MessageInfos:      file_index_proto_msgTypes,
+	}.Build()
+	File_index_proto = out.File
+	file_index_proto_goTypes = nil
+	file_index_proto_depIdxs = nil
+}
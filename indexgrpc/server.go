@@ -0,0 +1,184 @@
+// Package indexgrpc serves an index.Index over gRPC as the IndexService of index.proto -- with a client for it
+//
+// index.pb.go and index_grpc.pb.go are generated from index.proto -- after changing it run "go generate" here (with
+// protoc, protoc-gen-go and protoc-gen-go-grpc installed) to generate them again
+//
+package indexgrpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative index.proto
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/apwoodhouse/index"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type indexServer struct {
+	UnimplementedIndexServiceServer
+	indexStructure *index.Index
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func int64s(numbers []int) (wireNumbers []int64) {
+	wireNumbers = make([]int64, len(numbers))
+	for i, number := range numbers {
+		wireNumbers[i] = int64(number)
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func elementsResponse(success bool, results []int) *ElementsResponse {
+	return &ElementsResponse{Success: success, Elements: int64s(results)}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (server *indexServer) Insert(ctx context.Context, request *ChangeRequest) (*ChangeResponse, error) {
+	return &ChangeResponse{Success: index.Insert(request.Key, int(request.Element), server.indexStructure)}, nil
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (server *indexServer) Delete(ctx context.Context, request *ChangeRequest) (*ChangeResponse, error) {
+	return &ChangeResponse{Success: index.Delete(request.Key, int(request.Element), server.indexStructure)}, nil
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (server *indexServer) Select(ctx context.Context, request *KeyRequest) (*ElementsResponse, error) {
+	return elementsResponse(index.Select(request.Key, server.indexStructure)), nil
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (server *indexServer) Search(ctx context.Context, request *KeyRequest) (*ElementsResponse, error) {
+	return elementsResponse(index.Search(request.Key, server.indexStructure)), nil
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (server *indexServer) Range(ctx context.Context, request *RangeRequest) (*ElementsResponse, error) {
+	return elementsResponse(index.Range(request.From, request.To, server.indexStructure)), nil
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (server *indexServer) Stats(ctx context.Context, request *StatsRequest) (*StatsResponse, error) {
+	result, _ := index.Statistics(server.indexStructure)
+	return &StatsResponse{
+		Active:                 int64(result.Active),
+		Deleted:                int64(result.Deleted),
+		Depth:                  int64(result.Depth),
+		DecisionNodes:          int64(result.DecisionNodeCount),
+		CharacterNodes:         int64(result.CharacterNodeCount),
+		IndexKeyNodes:          int64(result.IndexKeyNodeCount),
+		IndexTerminalNodes:     int64(result.IndexTerminalNodeCount),
+		DuplicateKeyNodes:      int64(result.DuplicateKeyNodeCount),
+		DuplicateTerminalNodes: int64(result.DuplicateTerminalNodeCount),
+		NodeBytes:              int64(result.NodeBytes),
+		DeletedRatio:           result.DeletedRatio,
+		KeyLengths:             int64s(result.KeyLengths),
+		BranchingFactors:       int64s(result.BranchingFactors),
+		LargestDuplicateCount:  int64(result.LargestDuplicateCount),
+		LargestDuplicateKey:    result.LargestDuplicateKey,
+		AveragePathLength:      result.AveragePathLength,
+		MaximumPathLength:      int64(result.MaximumPathLength),
+	}, nil
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (server *indexServer) Scan(request *ScanRequest, stream grpc.ServerStreamingServer[ScanEntry]) error {
+	// sends every key after the one asked for -- each found only as the one before has been sent
+	for entry := range index.Entries(string(request.After), server.indexStructure) {
+		message := &ScanEntry{Key: []byte(entry.Key), Elements: int64s(entry.Elements)}
+		if err := stream.Send(message); err != nil { // the client has gone (or the stream is broken)
+			return err
+		}
+	}
+	return nil
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (server *indexServer) Watch(request *WatchRequest, stream grpc.ServerStreamingServer[WatchEvent]) error {
+	// sends every change until the client goes -- or the index drops the subscription, which the client is told
+	// about as ResourceExhausted so it knows it has missed changes
+	eventChannel := index.Subscribe(server.indexStructure)
+	defer index.Unsubscribe(eventChannel, server.indexStructure)
+	//
+	if err := stream.SendHeader(nil); err != nil { // so the client knows it is subscribed
+		return err
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case event, open := <-eventChannel:
+			if !open {
+				return status.Error(codes.ResourceExhausted, "index: watch fell behind or the content was replaced")
+			}
+			message := &WatchEvent{Op: WatchEvent_OP_INSERT, Key: []byte(event.Key), Element: int64(event.Element),
+				Seq: event.Seq}
+			if event.Op == index.EventDelete {
+				message.Op = WatchEvent_OP_DELETE
+			}
+			if err := stream.Send(message); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Register adds the IndexService for the index to a gRPC server -- before the server is started
+//
+func Register(grpcServer grpc.ServiceRegistrar, indexStructure *index.Index) {
+	RegisterIndexServiceServer(grpcServer, &indexServer{indexStructure: indexStructure})
+}
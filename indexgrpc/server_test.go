@@ -0,0 +1,258 @@
+package indexgrpc
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/apwoodhouse/index"
+)
+
+func newTestClient(t *testing.T, indexStructure *index.Index) (client *Client) {
+	// a server for the index listening in memory -- stopped when the test ends
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	Register(grpcServer, indexStructure)
+	go grpcServer.Serve(listener)
+	clientConnection, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		clientConnection.Close()
+		grpcServer.Stop()
+	})
+	client = NewClient(clientConnection)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestClientCalls(t *testing.T) {
+	// each call is made in turn through the client -- the changes are seen by the calls after them
+	indexStructure := new(index.Index)
+	index.Initialise(indexStructure)
+	client := newTestClient(t, indexStructure)
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		call    func() (bool, []int, error)
+		success bool
+		results []int
+	}{
+		{"insert", func() (bool, []int, error) {
+			success, err := client.Insert(ctx, "apple", 1)
+			return success, nil, err
+		}, true, nil},
+		{"insert a duplicate", func() (bool, []int, error) {
+			success, err := client.Insert(ctx, "apple", 2)
+			return success, nil, err
+		}, true, nil},
+		{"insert again", func() (bool, []int, error) {
+			success, err := client.Insert(ctx, "apple", 2)
+			return success, nil, err
+		}, false, nil},
+		{"insert another", func() (bool, []int, error) {
+			success, err := client.Insert(ctx, "banana", 3)
+			return success, nil, err
+		}, true, nil},
+		{"select", func() (bool, []int, error) { return client.Select(ctx, "apple") }, true, []int{1, 2}},
+		{"select missing", func() (bool, []int, error) { return client.Select(ctx, "cherry") }, false, nil},
+		{"search", func() (bool, []int, error) { return client.Search(ctx, "b") }, true, []int{3}},
+		{"range", func() (bool, []int, error) { return client.Range(ctx, "a", "b") }, true, []int{1, 2}},
+		{"open range", func() (bool, []int, error) { return client.Range(ctx, "", "") }, true, []int{1, 2, 3}},
+		{"delete", func() (bool, []int, error) {
+			success, err := client.Delete(ctx, "apple", 1)
+			return success, nil, err
+		}, true, nil},
+		{"delete missing", func() (bool, []int, error) {
+			success, err := client.Delete(ctx, "apple", 1)
+			return success, nil, err
+		}, false, nil},
+		{"deleted", func() (bool, []int, error) { return client.Select(ctx, "apple") }, true, []int{2}},
+	}
+	for _, test := range tests {
+		success, results, err := test.call()
+		if err != nil || success != test.success || !slices.Equal(results, test.results) {
+			t.Errorf("%s: got %v %v %v, want %v %v", test.name, success, results, err, test.success, test.results)
+		}
+	}
+	result, err := client.Stats(ctx)
+	if want, _ := index.Statistics(indexStructure); err != nil || !reflect.DeepEqual(result, want) {
+		t.Errorf("Stats returned %+v %v, want %+v", result, err, want)
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestClientScan(t *testing.T) {
+	// the stored keys are binary -- every one after the key asked for comes back in order, and stopping the loop
+	// early ends the stream
+	indexStructure := new(index.Index)
+	index.Initialise(indexStructure)
+	index.SetCollation(func(keyString string) string { return "\xff" + keyString }, indexStructure)
+	for i := range 1000 {
+		index.Insert("key"+strconv.Itoa(10000 + i)[1:], i, indexStructure)
+	}
+	client := newTestClient(t, indexStructure)
+	want, _ := index.ScanKeys("", 2000, indexStructure)
+	tests := []struct {
+		name     string
+		afterKey string
+		stopAt   int // entries to take before stopping the loop -- zero for all of them
+		want     []index.KeyEntry
+	}{
+		{"every key", "", 0, want},
+		{"after a key", want[499].Key, 0, want[500:]},
+		{"after the last key", want[999].Key, 0, nil},
+		{"stopped early", "", 10, want[:10]},
+	}
+	for _, test := range tests {
+		var got []index.KeyEntry
+		for entry, err := range client.Scan(context.Background(), test.afterKey) {
+			if err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+			if got = append(got, entry); len(got) == test.stopAt {
+				break
+			}
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %d entries, want %d", test.name, len(got), len(test.want))
+		}
+	}
+	// a cancelled call ends the loop with the error
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, err := range client.Scan(ctx, "") {
+		if status.Code(err) != codes.Canceled {
+			t.Errorf("a cancelled Scan returned %v", err)
+		}
+		break
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func watchChanges(t *testing.T, client *Client, indexStructure *index.Index, changes func(),
+	eventCount int) (events []index.Event, err error) {
+	// watches the index -- "tick" is inserted over and over until the first comes back (so the server is subscribed)
+	// then the changes are made and the events after the ticks collected until there are enough or the watch fails
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stopTicks, ticksStopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(ticksStopped)
+		for i := 0; ; i++ {
+			select {
+			case <-stopTicks:
+				return
+			case <-time.After(time.Millisecond):
+				index.Insert("tick", i, indexStructure)
+			}
+		}
+	}()
+	defer func() {
+		select {
+		case <-stopTicks:
+		default:
+			close(stopTicks)
+		}
+		<-ticksStopped
+	}()
+	for event, watchErr := range client.Watch(ctx) {
+		if err = watchErr; err != nil {
+			return
+		}
+		if event.Key == "tick" {
+			select {
+			case <-stopTicks:
+			default: // the first -- the server is subscribed
+				close(stopTicks)
+				<-ticksStopped
+				changes()
+			}
+			continue
+		}
+		if events = append(events, event); len(events) == eventCount {
+			return
+		}
+	}
+	t.Fatal("the watch ended without an error")
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestClientWatch(t *testing.T) {
+	// changes made once the watch has started come back in order
+	indexStructure := new(index.Index)
+	index.Initialise(indexStructure)
+	index.Insert("apple", 1, indexStructure) // made before the watch so not seen
+	client := newTestClient(t, indexStructure)
+	events, err := watchChanges(t, client, indexStructure, func() {
+		index.Insert("apple", 2, indexStructure)
+		index.Insert("banana", 3, indexStructure)
+		index.Delete("apple", 1, indexStructure)
+	}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []index.Event{
+		{Op: index.EventInsert, Key: "apple", Element: 2},
+		{Op: index.EventInsert, Key: "banana", Element: 3},
+		{Op: index.EventDelete, Key: "apple", Element: 1},
+	}
+	for i := range want {
+		want[i].Seq = events[0].Seq + uint64(i)
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("got %v, want %v", events, want)
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestClientWatchContentReplaced(t *testing.T) {
+	// the server drops the watch when the whole content is replaced -- the client is told with ResourceExhausted
+	indexStructure := new(index.Index)
+	index.Initialise(indexStructure)
+	var savedContent bytes.Buffer
+	if err := index.Save(&savedContent, indexStructure); err != nil {
+		t.Fatal(err)
+	}
+	client := newTestClient(t, indexStructure)
+	events, err := watchChanges(t, client, indexStructure, func() {
+		if err := index.Load(&savedContent, indexStructure); err != nil {
+			t.Error(err)
+		}
+	}, 1)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("got %v %v, want a ResourceExhausted error", events, err)
+	}
+}
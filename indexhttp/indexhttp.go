@@ -0,0 +1,234 @@
+// Package indexhttp serves an index.Index as JSON over HTTP
+//
+//   POST /insert       {"key": "...", "element": 1}         -> {"success": true}
+//   POST /delete       {"key": "...", "element": 1}         -> {"success": true}
+//   GET  /select       ?key=...                             -> {"success": true, "elements": [1, 2]}
+//   GET  /search       ?prefix=...                          -> {"success": true, "elements": [1, 2]}
+//   GET  /range        ?from=...&to=...                     -> {"success": true, "elements": [1, 2]}
+//   GET  /scan         ?after=<next>&limit=100              -> {"entries": [{"key": "<base64>", "elements": [1]}],
+//                                                               "next": "..."}
+//   GET  /count                                             -> {"count": 2}
+//   GET  /statistics                                        -> the index.Statistic JSON
+//
+// the keys /scan returns are as stored (after any collation, so not always text) and base64 encoded -- "next" is
+// URL-safe base64 so it can be passed straight back as 'after'
+//
+// failures are returned as {"error": "..."} with a 4xx status
+//
+package indexhttp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/apwoodhouse/index"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//  CONSTANTS and PSEUDO-CONSTANTS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+const (
+	defaultScanLimit = 100
+	maximumScanLimit = 10000
+	maximumBodyBytes = 4096 // far more than a key and element need
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type changeRequest struct {
+	Key     *string `json:"key"`
+	Element *int    `json:"element"`
+}
+
+type successResponse struct {
+	Success bool `json:"success"`
+}
+
+type elementsResponse struct {
+	Success  bool  `json:"success"`
+	Elements []int `json:"elements"`
+}
+
+type scanEntry struct {
+	Key      []byte `json:"key"` // base64 -- a stored key may be binary
+	Elements []int  `json:"elements"`
+}
+
+type scanResponse struct {
+	Entries []scanEntry `json:"entries"`
+	Next    string      `json:"next,omitempty"` // pass as 'after' for the next page -- missing on the last page
+}
+
+type countResponse struct {
+	Count int `json:"count"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func writeJSON(responseWriter http.ResponseWriter, statusCode int, response any) {
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.WriteHeader(statusCode)
+	json.NewEncoder(responseWriter).Encode(response)
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func writeError(responseWriter http.ResponseWriter, statusCode int, message string) {
+	writeJSON(responseWriter, statusCode, errorResponse{Error: message})
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func writeElements(responseWriter http.ResponseWriter, success bool, results []int) {
+	if results == nil { // an empty array rather than null
+		results = []int{}
+	}
+	writeJSON(responseWriter, http.StatusOK, elementsResponse{Success: success, Elements: results})
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func changeHandler(indexStructure *index.Index, readOnly bool,
+	change func(keyInput string, keyElement int, indexStructure *index.Index) bool) http.HandlerFunc {
+	// handles /insert and /delete -- which both take a key and an element
+	return func(responseWriter http.ResponseWriter, request *http.Request) {
+		if readOnly {
+			writeError(responseWriter, http.StatusForbidden, "index is read-only")
+			return
+		}
+		var body changeRequest
+		decoder := json.NewDecoder(http.MaxBytesReader(responseWriter, request.Body, maximumBodyBytes))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&body); err != nil {
+			writeError(responseWriter, http.StatusBadRequest, "body must be {\"key\": string, \"element\": number}")
+			return
+		}
+		if body.Key == nil || body.Element == nil {
+			writeError(responseWriter, http.StatusBadRequest, "both \"key\" and \"element\" are needed")
+			return
+		}
+		success := change(*body.Key, *body.Element, indexStructure)
+		writeJSON(responseWriter, http.StatusOK, successResponse{Success: success})
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func scanHandler(indexStructure *index.Index) http.HandlerFunc {
+	// handles /scan -- a page of keys at a time, each page starting after the 'next' of the one before
+	return func(responseWriter http.ResponseWriter, request *http.Request) {
+		query := request.URL.Query()
+		keyLimit := defaultScanLimit
+		if limitParameter := query.Get("limit"); limitParameter != "" {
+			var err error
+			keyLimit, err = strconv.Atoi(limitParameter)
+			if err != nil || keyLimit < 1 || keyLimit > maximumScanLimit {
+				writeError(responseWriter, http.StatusBadRequest,
+					"limit must be a number from 1 to "+strconv.Itoa(maximumScanLimit))
+				return
+			}
+		}
+		afterKey, err := base64.RawURLEncoding.DecodeString(query.Get("after")) // the stored key may be binary
+		if err != nil {
+			writeError(responseWriter, http.StatusBadRequest, "after must be the next value of the previous page")
+			return
+		}
+		entries, more := index.ScanKeys(string(afterKey), keyLimit, indexStructure)
+		response := scanResponse{Entries: make([]scanEntry, len(entries))}
+		for i, entry := range entries {
+			response.Entries[i] = scanEntry{Key: []byte(entry.Key), Elements: entry.Elements}
+		}
+		if more {
+			response.Next = base64.RawURLEncoding.EncodeToString([]byte(entries[len(entries)-1].Key))
+		}
+		writeJSON(responseWriter, http.StatusOK, response)
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func newHandler(indexStructure *index.Index, readOnly bool) http.Handler {
+	serveMux := http.NewServeMux()
+	serveMux.HandleFunc("POST /insert", changeHandler(indexStructure, readOnly, index.Insert))
+	serveMux.HandleFunc("POST /delete", changeHandler(indexStructure, readOnly, index.Delete))
+	serveMux.HandleFunc("GET /select", func(responseWriter http.ResponseWriter, request *http.Request) {
+		success, results := index.Select(request.URL.Query().Get("key"), indexStructure)
+		writeElements(responseWriter, success, results)
+	})
+	serveMux.HandleFunc("GET /search", func(responseWriter http.ResponseWriter, request *http.Request) {
+		success, results := index.Search(request.URL.Query().Get("prefix"), indexStructure)
+		writeElements(responseWriter, success, results)
+	})
+	serveMux.HandleFunc("GET /range", func(responseWriter http.ResponseWriter, request *http.Request) {
+		query := request.URL.Query()
+		success, results := index.Range(query.Get("from"), query.Get("to"), indexStructure)
+		writeElements(responseWriter, success, results)
+	})
+	serveMux.HandleFunc("GET /scan", scanHandler(indexStructure))
+	serveMux.HandleFunc("GET /count", func(responseWriter http.ResponseWriter, request *http.Request) {
+		writeJSON(responseWriter, http.StatusOK, countResponse{Count: index.Count(indexStructure)})
+	})
+	serveMux.HandleFunc("GET /statistics", func(responseWriter http.ResponseWriter, request *http.Request) {
+		result, _ := index.Statistics(indexStructure)
+		writeJSON(responseWriter, http.StatusOK, result)
+	})
+	return serveMux
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Handler returns an http.Handler serving every endpoint for the index -- mount it under a prefix with
+//         http.StripPrefix if needed
+//
+func Handler(indexStructure *index.Index) http.Handler {
+	return newHandler(indexStructure, false)
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// ReadOnlyHandler returns an http.Handler as Handler but /insert and /delete are refused (403 Forbidden)
+//
+func ReadOnlyHandler(indexStructure *index.Index) http.Handler {
+	return newHandler(indexStructure, true)
+}
@@ -0,0 +1,196 @@
+package indexhttp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apwoodhouse/index"
+)
+
+func newTestIndex() (indexStructure *index.Index) {
+	// "apple" holds two elements -- the rest one each
+	indexStructure = new(index.Index)
+	index.Initialise(indexStructure)
+	index.Insert("apple", 1, indexStructure)
+	index.Insert("apple", 2, indexStructure)
+	index.Insert("apply", 3, indexStructure)
+	index.Insert("banana", 4, indexStructure)
+	index.Insert("cherry", 5, indexStructure)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func serve(handler http.Handler, method, target, body string) (statusCode int, responseBody string) {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	statusCode, responseBody = recorder.Code, strings.TrimSpace(recorder.Body.String())
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestHandler(t *testing.T) {
+	// each request is made in turn to the same index -- the changes are seen by the requests after them
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		statusCode int
+		response   string
+	}{
+		{"select", "GET", "/select?key=apple", "", 200, `{"success":true,"elements":[1,2]}`},
+		{"select missing", "GET", "/select?key=date", "", 200, `{"success":false,"elements":[]}`},
+		{"search", "GET", "/search?prefix=app", "", 200, `{"success":true,"elements":[1,2,3]}`},
+		{"range", "GET", "/range?from=apply&to=banana", "", 200, `{"success":true,"elements":[3,4]}`},
+		{"open range", "GET", "/range?from=b", "", 200, `{"success":true,"elements":[4,5]}`},
+		{"count", "GET", "/count", "", 200, `{"count":5}`},
+		{"insert", "POST", "/insert", `{"key": "date", "element": 6}`, 200, `{"success":true}`},
+		{"insert again", "POST", "/insert", `{"key": "date", "element": 6}`, 200, `{"success":false}`},
+		{"inserted", "GET", "/select?key=date", "", 200, `{"success":true,"elements":[6]}`},
+		{"delete", "POST", "/delete", `{"key": "apple", "element": 1}`, 200, `{"success":true}`},
+		{"delete missing", "POST", "/delete", `{"key": "apple", "element": 1}`, 200, `{"success":false}`},
+		{"deleted", "GET", "/select?key=apple", "", 200, `{"success":true,"elements":[2]}`},
+		{"count after", "GET", "/count", "", 200, `{"count":5}`},
+		{"insert without an element", "POST", "/insert", `{"key": "date"}`, 400,
+			`{"error":"both \"key\" and \"element\" are needed"}`},
+		{"insert with an unknown field", "POST", "/insert", `{"key": "date", "element": 7, "value": 1}`, 400,
+			`{"error":"body must be {\"key\": string, \"element\": number}"}`},
+		{"delete not JSON", "POST", "/delete", `date`, 400,
+			`{"error":"body must be {\"key\": string, \"element\": number}"}`},
+		{"insert too big", "POST", "/insert", `{"key": "` + strings.Repeat("a", maximumBodyBytes) + `"}`, 400,
+			`{"error":"body must be {\"key\": string, \"element\": number}"}`},
+		{"insert by GET", "GET", "/insert", "", 405, `Method Not Allowed`},
+		{"scan limit too big", "GET", "/scan?limit=10001", "", 400,
+			`{"error":"limit must be a number from 1 to 10000"}`},
+		{"scan limit not a number", "GET", "/scan?limit=x", "", 400,
+			`{"error":"limit must be a number from 1 to 10000"}`},
+		{"scan after not base64", "GET", "/scan?after=!", "", 400,
+			`{"error":"after must be the next value of the previous page"}`},
+	}
+	handler := Handler(newTestIndex())
+	for _, test := range tests {
+		statusCode, response := serve(handler, test.method, test.target, test.body)
+		if statusCode != test.statusCode || response != test.response {
+			t.Errorf("%s: got %d %s, want %d %s", test.name, statusCode, response, test.statusCode, test.response)
+		}
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestReadOnlyHandler(t *testing.T) {
+	indexStructure := newTestIndex()
+	handler := ReadOnlyHandler(indexStructure)
+	for _, target := range []string{"/insert", "/delete"} {
+		statusCode, response := serve(handler, "POST", target, `{"key": "date", "element": 6}`)
+		if statusCode != http.StatusForbidden || response != `{"error":"index is read-only"}` {
+			t.Errorf("%s: got %d %s", target, statusCode, response)
+		}
+	}
+	if statusCode, response := serve(handler, "GET", "/count", ""); statusCode != 200 || response != `{"count":5}` {
+		t.Errorf("/count: got %d %s", statusCode, response)
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestScanPages(t *testing.T) {
+	// the stored keys are binary (the collation puts a byte that isn't UTF-8 in front) -- paging through them a few
+	// at a time, passing each "next" straight back as "after", must give every key once, in order
+	indexStructure := new(index.Index)
+	index.Initialise(indexStructure)
+	index.SetCollation(func(keyString string) string { return "\xff\xfe" + keyString }, indexStructure)
+	keyStrings := []string{"a", "b", "b?", "c>", "d", "e~", "f", "g"}
+	for i, keyString := range keyStrings {
+		index.Insert(keyString, i, indexStructure)
+	}
+	handler := Handler(indexStructure)
+	var scanned []scanEntry
+	nextKey, pageCount := "", 0
+	for {
+		target := "/scan?limit=3"
+		if nextKey != "" {
+			target += "&after=" + nextKey // URL-safe base64 needs no escaping
+		}
+		statusCode, body := serve(handler, "GET", target, "")
+		var response scanResponse
+		if err := json.Unmarshal([]byte(body), &response); statusCode != 200 || err != nil {
+			t.Fatalf("%s: got %d %s (%v)", target, statusCode, body, err)
+		}
+		if nextKey != "" && url.QueryEscape(nextKey) != nextKey {
+			t.Errorf("next %q is not URL-safe", nextKey)
+		}
+		scanned = append(scanned, response.Entries...)
+		pageCount++
+		if response.Next == "" {
+			break
+		}
+		nextKey = response.Next
+	}
+	var want []scanEntry
+	for i, keyString := range keyStrings {
+		want = append(want, scanEntry{Key: []byte("\xff\xfe" + keyString), Elements: []int{i}})
+	}
+	if !reflect.DeepEqual(scanned, want) || pageCount != 3 {
+		t.Errorf("got %v in %d pages, want %v in 3", scanned, pageCount, want)
+	}
+	// the key itself is standard base64 in the JSON
+	_, body := serve(handler, "GET", "/scan?limit=1", "")
+	wantKey := base64.StdEncoding.EncodeToString([]byte("\xff\xfea"))
+	if !strings.Contains(body, `"key":"`+wantKey+`"`) {
+		t.Errorf("got %s, want the key as %s", body, wantKey)
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestStatisticsEndpoint(t *testing.T) {
+	// the second index stores binary keys -- the largest duplicate key must come back byte for byte
+	binaryIndex := new(index.Index)
+	index.Initialise(binaryIndex)
+	index.SetCollation(func(keyString string) string { return "\xff\xfe" + keyString }, binaryIndex)
+	index.Insert("apple", 1, binaryIndex)
+	index.Insert("apple", 2, binaryIndex)
+	index.Insert("banana", 3, binaryIndex)
+	tests := []struct {
+		name           string
+		indexStructure *index.Index
+		duplicateKey   string
+	}{
+		{"text keys", newTestIndex(), "apple"},
+		{"binary keys", binaryIndex, "\xff\xfeapple"},
+	}
+	for _, test := range tests {
+		statusCode, body := serve(Handler(test.indexStructure), "GET", "/statistics", "")
+		var result index.Statistic
+		if err := json.Unmarshal([]byte(body), &result); statusCode != 200 || err != nil {
+			t.Fatalf("%s: got %d %s (%v)", test.name, statusCode, body, err)
+		}
+		if want, _ := index.Statistics(test.indexStructure); !reflect.DeepEqual(result, want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, result, want)
+		}
+		if string(result.LargestDuplicateKey) != test.duplicateKey {
+			t.Errorf("%s: got the largest duplicate key %q, want %q", test.name, result.LargestDuplicateKey,
+				test.duplicateKey)
+		}
+	}
+}
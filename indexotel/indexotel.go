@@ -0,0 +1,86 @@
+// Package indexotel traces an index.Index with OpenTelemetry -- an index.Tracer that starts a span called
+// "index.<operation>" (index.insert, index.search ...) for each call with the attributes
+//
+//   index.name                 the name given to New -- to tell one index from another
+//   index.key_prefix_length    the length of the key, search prefix or start of the range passed in
+//   index.result_count         the number of elements returned
+//   index.nodes_visited        how many nodes of the index the call looked at
+//
+package indexotel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/apwoodhouse/index"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Tracer is an index.Tracer that starts OpenTelemetry spans -- see New
+//
+type Tracer struct {
+	tracer    trace.Tracer
+	indexName attribute.KeyValue
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type span struct {
+	span trace.Span
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (tracedSpan span) End(attributes index.SpanAttributes) {
+	tracedSpan.span.SetAttributes(
+		attribute.Int("index.key_prefix_length", attributes.KeyLength),
+		attribute.Int("index.result_count", attributes.ResultCount),
+		attribute.Int("index.nodes_visited", attributes.NodesVisited),
+	)
+	tracedSpan.span.End()
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// New returns a Tracer starting its spans with 'tracer' (otel.Tracer("github.com/apwoodhouse/index") for the global
+//     provider) -- 'indexName' tells one index from another -- pass the result to index.SetTracer to start tracing
+//
+func New(tracer trace.Tracer, indexName string) (indexTracer *Tracer) {
+	indexTracer = &Tracer{tracer: tracer, indexName: attribute.String("index.name", indexName)}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// StartSpan starts an internal span for a call -- a child of any span already in 'ctx'
+//
+func (indexTracer *Tracer) StartSpan(ctx context.Context, operation index.Operation) index.Span {
+	_, tracedSpan := indexTracer.tracer.Start(ctx, "index."+string(operation),
+		trace.WithSpanKind(trace.SpanKindInternal), trace.WithAttributes(indexTracer.indexName))
+	return span{tracedSpan}
+}
@@ -0,0 +1,175 @@
+// Package indexprometheus measures an index.Index for Prometheus -- an index.Metrics that keeps
+//
+//   index_operations_total{index, operation, result}          calls made -- 'result' is "hit" or "miss"
+//   index_operation_duration_seconds{index, operation}        a histogram of how long calls took once the index
+//                                                             was free
+//   index_lock_wait_duration_seconds{index, operation}        a histogram of how long calls waited for the index
+//   index_keys{index}, index_nodes{index}, index_deleted_nodes{index}   the index.Sizes when scraped
+//
+package indexprometheus
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/apwoodhouse/index"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//  CONSTANTS and PSEUDO-CONSTANTS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+var durationBuckets = prometheus.ExponentialBuckets(1e-6, 4, 12) // 1µs up to about 4s
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Metrics is an index.Metrics kept as Prometheus metrics -- see New
+//
+type Metrics struct {
+	operations   *prometheus.CounterVec
+	latency      *prometheus.HistogramVec
+	lockWait     *prometheus.HistogramVec
+	sizeGauges   sizeCollector
+	registerer   prometheus.Registerer
+	collectorSet []prometheus.Collector
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type sizeCollector struct { // reads the gauges from the index only when scraped
+	indexStructure     *index.Index
+	keysDescription    *prometheus.Desc
+	nodesDescription   *prometheus.Desc
+	deletedDescription *prometheus.Desc
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (collector *sizeCollector) Describe(descriptions chan<- *prometheus.Desc) {
+	descriptions <- collector.keysDescription
+	descriptions <- collector.nodesDescription
+	descriptions <- collector.deletedDescription
+}
+
+func (collector *sizeCollector) Collect(metrics chan<- prometheus.Metric) {
+	keyCount, nodeCount, deletedCount := index.Sizes(collector.indexStructure)
+	metrics <- prometheus.MustNewConstMetric(collector.keysDescription, prometheus.GaugeValue, float64(keyCount))
+	metrics <- prometheus.MustNewConstMetric(collector.nodesDescription, prometheus.GaugeValue, float64(nodeCount))
+	metrics <- prometheus.MustNewConstMetric(collector.deletedDescription, prometheus.GaugeValue,
+		float64(deletedCount))
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// New registers the metrics for an index with 'registerer' (prometheus.DefaultRegisterer for the usual /metrics)
+//     -- 'indexName' is the "index" label that tells one index from another -- then pass the result to
+//     index.SetMetrics to start measuring
+//
+func New(registerer prometheus.Registerer, indexName string, indexStructure *index.Index) (metrics *Metrics,
+	err error) {
+	indexLabel := prometheus.Labels{"index": indexName}
+	metrics = &Metrics{
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "index_operations_total",
+			Help:        "Calls made on the index by operation and whether they were a hit or a miss.",
+			ConstLabels: indexLabel,
+		}, []string{"operation", "result"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        "index_operation_duration_seconds",
+			Help:        "How long calls on the index took once it was free.",
+			ConstLabels: indexLabel,
+			Buckets:     durationBuckets,
+		}, []string{"operation"}),
+		lockWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        "index_lock_wait_duration_seconds",
+			Help:        "How long calls on the index waited for it to be free.",
+			ConstLabels: indexLabel,
+			Buckets:     durationBuckets,
+		}, []string{"operation"}),
+		sizeGauges: sizeCollector{
+			indexStructure: indexStructure,
+			keysDescription: prometheus.NewDesc("index_keys", "Keys (and element numbers) held by the index.",
+				nil, indexLabel),
+			nodesDescription: prometheus.NewDesc("index_nodes", "Nodes in the node array, in use or deleted.",
+				nil, indexLabel),
+			deletedDescription: prometheus.NewDesc("index_deleted_nodes",
+				"Nodes on the deleted list waiting to be re-used.", nil, indexLabel),
+		},
+		registerer: registerer,
+	}
+	for _, collector := range []prometheus.Collector{
+		metrics.operations, metrics.latency, metrics.lockWait, &metrics.sizeGauges,
+	} {
+		if err = registerer.Register(collector); err != nil {
+			metrics.Unregister() // the ones already registered
+			metrics = nil
+			return
+		}
+		metrics.collectorSet = append(metrics.collectorSet, collector)
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Unregister takes the metrics out of the registerer they were registered with -- call index.SetMetrics(nil, ...)
+//            first to stop measuring
+//
+func (metrics *Metrics) Unregister() {
+	for _, collector := range metrics.collectorSet {
+		metrics.registerer.Unregister(collector)
+	}
+	metrics.collectorSet = nil
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// ObserveLockWait adds a wait for the index to the histogram
+//
+func (metrics *Metrics) ObserveLockWait(operation index.Operation, lockWait time.Duration) {
+	metrics.lockWait.WithLabelValues(string(operation)).Observe(lockWait.Seconds())
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// ObserveOperation counts a call as a hit or miss and adds how long it took to the histogram
+//
+func (metrics *Metrics) ObserveOperation(operation index.Operation, hit bool, latency time.Duration) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	metrics.operations.WithLabelValues(string(operation), result).Inc()
+	metrics.latency.WithLabelValues(string(operation)).Observe(latency.Seconds())
+}
@@ -0,0 +1,271 @@
+package index
+
+import (
+	"context"
+	"errors"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//  CONSTANTS and PSEUDO-CONSTANTS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// ErrBadPattern is returned by Match for a pattern with an unclosed or empty '[' class or a trailing '\'
+var ErrBadPattern = errors.New("index: malformed pattern")
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type patternToken struct {
+	anyRun     bool      // a '*' -- matches any run of characters, including none
+	literal    bool      // a single character given as itself (or escaped) rather than by '?' or a class
+	character  byte      // the character when 'literal'
+	characters [4]uint64 // the characters it matches -- one bit for each
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type patternMatcher struct {
+	patternTokens []patternToken
+	stateSets     prefixMemo[[]bool] // [i][t] -- after i characters of a prefix the pattern could be at token t
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (token *patternToken) addCharacters(lowCharacter, highCharacter byte) {
+	for c := int(lowCharacter); c <= int(highCharacter); c++ {
+		token.characters[c>>6] |= 1 << (c & 63)
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (token *patternToken) matchesAny(lowCharacter, highCharacter byte) bool {
+	// does the token match any of the characters from 'lowCharacter' to 'highCharacter'?
+	if token.anyRun {
+		return true
+	}
+	for c := int(lowCharacter); c <= int(highCharacter); c++ {
+		if token.characters[c>>6]&(1<<(c&63)) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func parsePattern(pattern string) (patternTokens []patternToken, err error) {
+	// splits a Match pattern into one token for each character it matches (or '*')
+	for i := 0; i < len(pattern); i++ {
+		var token patternToken
+		switch pattern[i] {
+		case '*':
+			if len(patternTokens) > 0 && patternTokens[len(patternTokens)-1].anyRun { // "**" is the same as "*"
+				continue
+			}
+			token.anyRun = true
+		case '?':
+			token.addCharacters(0, 255)
+		case '\\':
+			if i++; i == len(pattern) {
+				err = ErrBadPattern
+				return
+			}
+			token.literal, token.character = true, pattern[i]
+			token.addCharacters(pattern[i], pattern[i])
+		case '[':
+			if i, err = parseClass(pattern, i+1, &token); err != nil {
+				return
+			}
+		default:
+			token.literal, token.character = true, pattern[i]
+			token.addCharacters(pattern[i], pattern[i])
+		}
+		patternTokens = append(patternTokens, token)
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func parseClass(pattern string, i int, token *patternToken) (classEnd int, err error) {
+	// reads a class -- "[abc]", "[a-c]" or negated "[^a-c]" (or "[!a-c]") -- starting just after the '[' and returns
+	// where its ']' is
+	negated := i < len(pattern) && (pattern[i] == '^' || pattern[i] == '!')
+	if negated {
+		i++
+	}
+	classStart := i
+	for ; i < len(pattern) && (pattern[i] != ']' || i == classStart); i++ { // a ']' first is one of the characters
+		lowCharacter := pattern[i]
+		if lowCharacter == '\\' {
+			if i++; i == len(pattern) {
+				break
+			}
+			lowCharacter = pattern[i]
+		}
+		highCharacter := lowCharacter
+		if i+2 < len(pattern) && pattern[i+1] == '-' && pattern[i+2] != ']' {
+			i += 2
+			if highCharacter = pattern[i]; highCharacter == '\\' && i+1 < len(pattern) {
+				i++
+				highCharacter = pattern[i]
+			}
+		}
+		if highCharacter < lowCharacter {
+			err = ErrBadPattern
+			return
+		}
+		token.addCharacters(lowCharacter, highCharacter)
+	}
+	if i >= len(pattern) { // never closed
+		err = ErrBadPattern
+		return
+	}
+	if negated {
+		for w := range token.characters {
+			token.characters[w] = ^token.characters[w]
+		}
+	}
+	classEnd = i
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (matcher *patternMatcher) followAnyRuns(stateSet []bool) {
+	// a '*' can match nothing -- so wherever the pattern could be at one it could be just past it too
+	for t, token := range matcher.patternTokens {
+		if stateSet[t] && token.anyRun {
+			stateSet[t+1] = true
+		}
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (matcher *patternMatcher) nextStates(previousSet []bool, keyCharacter byte) (stateSet []bool) {
+	// where the pattern could be after one more character
+	stateSet = make([]bool, len(previousSet))
+	for t, token := range matcher.patternTokens {
+		if !previousSet[t] {
+			continue
+		}
+		if token.anyRun {
+			stateSet[t] = true
+		} else if token.matchesAny(keyCharacter, keyCharacter) {
+			stateSet[t+1] = true
+		}
+	}
+	matcher.followAnyRuns(stateSet)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (matcher *patternMatcher) keyFilter(keyPrefix []byte, lowCharacter, highCharacter byte) bool {
+	// a 'keyWalker' filter that skips the branches no key of which can match the pattern
+	for t, possible := range stateAfter(keyPrefix, &matcher.stateSets)[:len(matcher.patternTokens)] {
+		if possible && matcher.patternTokens[t].matchesAny(lowCharacter, highCharacter) {
+			return true
+		}
+	}
+	return false
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func matchKeys(patternTokens []patternToken, indexStructure *Index) (results []int, nodesVisited int) {
+	// collects the elements of every key matching the pattern -- going straight to the keys starting with its literal
+	// prefix (as Search does) and walking only the branches below that could match the rest
+	matcher := patternMatcher{patternTokens: patternTokens}
+	startSet := make([]bool, len(patternTokens)+1)
+	startSet[0] = true
+	matcher.followAnyRuns(startSet)
+	matcher.stateSets = newPrefixMemo(startSet, matcher.nextStates)
+	walker := keyWalker{indexStructure: indexStructure, keyFilter: matcher.keyFilter}
+	walker.keyVisit = func(keyString []byte, keyPointer int) bool {
+		if stateAfter(keyString, &matcher.stateSets)[len(patternTokens)] {
+			results = append(results, walker.collectElements(keyPointer)...)
+		}
+		return true
+	}
+	var literalPrefix []byte
+	for _, token := range patternTokens {
+		if !token.literal {
+			break
+		}
+		literalPrefix = append(literalPrefix, token.character)
+	}
+	if len(literalPrefix) == 0 {
+		walkKeys(indexStructure.indexRootPointer, nil, 0, 255, &walker)
+		nodesVisited = walker.nodesVisited
+		return
+	}
+	prefixPointer, _, nodesVisited := findPrefix(string(literalPrefix), indexStructure)
+	if prefixPointer == nullIndexPointer { // no key starts with the prefix
+		return
+	}
+	lastCharacter := literalPrefix[len(literalPrefix)-1]
+	walkKeys(prefixPointer, literalPrefix[:len(literalPrefix)-1], lastCharacter, lastCharacter, &walker)
+	nodesVisited += walker.nodesVisited
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Match returns an array of zero or many Element numbers for every key matching a wildcard pattern, in key order
+//       '?' matches any one character, '*' any run of characters (including none) and "[a-c]" one of a class of
+//       characters ("[^a-c]" or "[!a-c]" one not in it) -- '\' makes the next character match only itself
+//       the pattern is matched against keys as stored -- it isn't passed through the index's Collation
+//       'success' is true if at least one result is found -- 'err' is ErrBadPattern if the pattern is malformed
+//
+func Match(pattern string, indexStructure *Index) (success bool, results []int, err error) {
+	timer := lockIndex(context.Background(), OperationMatch, len(pattern), indexStructure)
+	defer func() { unlockIndex(timer, success, len(results), indexStructure) }()
+	//
+	patternTokens, err := parsePattern(pattern)
+	if err != nil {
+		return
+	}
+	results, timer.nodesVisited = matchKeys(patternTokens, indexStructure)
+	success = len(results) > 0
+	return
+}
@@ -0,0 +1,92 @@
+package index
+
+import (
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func newFilterIndex(tb testing.TB, postingLists bool) (indexStructure *Index) {
+	// every key of one to three of "abc" and a few holding characters that patterns treat specially -- every
+	// third key holds a second element so some are 'duplicate' nodes
+	indexStructure = newDuplicatesIndex(tb, postingLists, "", 0)
+	keyStrings := []string{"a*c", "a?", "[a]", "abcd", "ab\\c"}
+	keyLevel := []string{""}
+	for range 3 {
+		var nextLevel []string
+		for _, keyPrefix := range keyLevel {
+			for _, keyCharacter := range "abc" {
+				nextLevel = append(nextLevel, keyPrefix+string(keyCharacter))
+			}
+		}
+		keyStrings, keyLevel = append(keyStrings, nextLevel...), nextLevel
+	}
+	for keyElement, keyString := range keyStrings {
+		Insert(keyString, keyElement, indexStructure)
+		if keyElement%3 == 0 {
+			Insert(keyString, keyElement+1000, indexStructure)
+		}
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func filterEntries(indexStructure *Index, keep func(keyString string) bool) (entries []KeyEntry) {
+	// the brute-force answer -- every key, in key order, that 'keep' accepts
+	for _, entry := range allEntries(indexStructure) {
+		if keep(entry.Key) {
+			entries = append(entries, entry)
+		}
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestMatch(t *testing.T) {
+	patterns := []string{
+		"", "*", "a*", "*c", "a?", "??", "???", "????", "a*b*c", "*a*b*", "b*a*", "abc", "abcd*", "x*",
+		"[ab]?", "[a-b]*[!c]", "[^ab]b*", "?[c-c]", "a\\*c", "a\\?", "\\[a]", "ab\\\\c", "*\\**", "[[]*",
+	}
+	for _, layout := range duplicateLayouts {
+		indexStructure := newFilterIndex(t, layout.postingLists)
+		for _, pattern := range patterns {
+			t.Run(layout.name+"/"+strconv.Quote(pattern), func(t *testing.T) {
+				modelPattern := strings.ReplaceAll(pattern, "[!", "[^") // path.Match has no "[!"
+				var want []int
+				for _, entry := range filterEntries(indexStructure, func(keyString string) bool {
+					matched, err := path.Match(modelPattern, keyString)
+					if err != nil {
+						t.Fatal(err)
+					}
+					return matched
+				}) {
+					want = append(want, entry.Elements...)
+				}
+				success, results, err := Match(pattern, indexStructure)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if success != (len(want) > 0) || !slices.Equal(results, want) {
+					t.Errorf("got %v %v, want %v", success, results, want)
+				}
+			})
+		}
+	}
+	for _, pattern := range []string{"[", "[a", "a\\", "[]", "[!]"} {
+		if success, results, err := Match(pattern, newFilterIndex(t, false)); err != ErrBadPattern {
+			t.Errorf("%q got %v %v %v, want ErrBadPattern", pattern, success, results, err)
+		}
+	}
+	if success, results, err := Match("*", newDuplicatesIndex(t, false, "", 0)); success || results != nil ||
+		err != nil {
+		t.Errorf("an empty index got %v %v %v", success, results, err)
+	}
+}
@@ -0,0 +1,277 @@
+package index
+
+import (
+	"context"
+	"expvar"
+	"strconv"
+	"time"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//  CONSTANTS and PSEUDO-CONSTANTS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+const (
+	// OperationInsert is the Operation of Insert and InsertMany
+	OperationInsert Operation = "insert"
+	// OperationDelete is the Operation of Delete
+	OperationDelete Operation = "delete"
+	// OperationSelect is the Operation of Select
+	OperationSelect Operation = "select"
+	// OperationSearch is the Operation of Search and SearchContext
+	OperationSearch Operation = "search"
+	// OperationScan is the Operation of Scan, ScanContext and ScanKeys
+	OperationScan Operation = "scan"
+	// OperationRange is the Operation of Range
+	OperationRange Operation = "range"
+	// OperationMatch is the Operation of Match
+	OperationMatch Operation = "match"
+	// OperationRegexp is the Operation of SearchRegexp
+	OperationRegexp Operation = "regexp"
+	// OperationFuzzy is the Operation of SearchFuzzy
+	OperationFuzzy Operation = "fuzzy"
+	// OperationApply is the Operation of Apply
+	OperationApply Operation = "apply"
+	// OperationTransaction is the Operation of Commit -- making a transaction's changes to the index
+	OperationTransaction Operation = "transaction"
+)
+
+// LatencyBuckets are the upper bounds of the latency histograms kept by ExpvarMetrics -- anything longer than the
+// last is counted as "inf"
+var LatencyBuckets = []time.Duration{
+	time.Microsecond, 10 * time.Microsecond, 100 * time.Microsecond,
+	time.Millisecond, 10 * time.Millisecond, 100 * time.Millisecond, time.Second,
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Operation names the kind of call a Metrics measurement (or a Tracer span) is for
+//
+type Operation string
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Metrics receives measurements of the calls made on an index -- see SetMetrics
+//         ObserveLockWait is how long a call waited for the index to be free, ObserveOperation how long it then
+//         took and whether it was a 'hit' (the call's 'success') -- both are made after the index is unlocked and
+//         may be made from many goroutines at once
+//
+type Metrics interface {
+	ObserveLockWait(operation Operation, lockWait time.Duration)
+	ObserveOperation(operation Operation, hit bool, latency time.Duration)
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type operationTimer struct { // an empty timer (no 'metrics' or 'span') measures nothing
+	metrics        Metrics
+	operation      Operation
+	waitStart      time.Time
+	operationStart time.Time
+	span           Span
+	keyLength      int
+	nodesVisited   int   // set by the call before it unlocks the index
+	lockErr        error // the context was cancelled before the index was locked -- so it isn't
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// ExpvarMetrics is a Metrics that publishes its measurements (and the sizes of the index) with the expvar package
+//
+type ExpvarMetrics struct {
+	operationMaps map[Operation]*expvar.Map
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func lockIndex(ctx context.Context, operation Operation, keyLength int, indexStructure *Index) (timer operationTimer) {
+	// locks the index for a call -- timing the wait (and starting the clock on the call) if it has metrics and
+	// starting a span (under any span in 'ctx') if it has a tracer -- if 'ctx' is cancelled first the index is left
+	// unlocked and 'lockErr' set (but 'unlockIndex' must still be called)
+	timer.operation = operation
+	if metricsPointer := indexStructure.metrics.Load(); metricsPointer != nil {
+		timer.metrics = *metricsPointer
+		timer.waitStart = time.Now()
+	}
+	if tracerPointer := indexStructure.tracer.Load(); tracerPointer != nil {
+		timer.span = (*tracerPointer).StartSpan(ctx, operation)
+		timer.keyLength = keyLength
+	}
+	timer.lockErr = indexStructure.indexMutex.lockContext(ctx)
+	if timer.metrics != nil {
+		timer.operationStart = time.Now()
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func unlockIndex(timer operationTimer, hit bool, resultCount int, indexStructure *Index) {
+	// unlocks the index after a call started by 'lockIndex' -- and passes on what was measured
+	if timer.metrics == nil && timer.span == nil {
+		if timer.lockErr == nil {
+			indexStructure.indexMutex.Unlock()
+		}
+		return
+	}
+	var latency time.Duration
+	if timer.metrics != nil {
+		latency = time.Since(timer.operationStart)
+	}
+	if timer.lockErr == nil {
+		indexStructure.indexMutex.Unlock()
+	}
+	if timer.metrics != nil {
+		timer.metrics.ObserveLockWait(timer.operation, timer.operationStart.Sub(timer.waitStart))
+		timer.metrics.ObserveOperation(timer.operation, hit, latency)
+	}
+	if timer.span != nil {
+		timer.span.End(SpanAttributes{KeyLength: timer.keyLength, ResultCount: resultCount,
+			NodesVisited: timer.nodesVisited})
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func latencyBucket(latency time.Duration) string {
+	// the name of the ExpvarMetrics histogram bucket for a latency -- its upper bound in microseconds
+	for _, bucketBound := range LatencyBuckets {
+		if latency <= bucketBound {
+			return "le_" + strconv.FormatInt(bucketBound.Microseconds(), 10) + "us"
+		}
+	}
+	return "inf"
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// SetMetrics has every call made on the index that has an Operation (Insert, Delete, Select, Search, Scan ...)
+//            measured by 'metrics' -- nil stops the measuring -- with no metrics set nothing is timed
+//
+func SetMetrics(metrics Metrics, indexStructure *Index) {
+	if metrics == nil {
+		indexStructure.metrics.Store(nil)
+		return
+	}
+	indexStructure.metrics.Store(&metrics)
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Sizes returns the gauges of an index -- the number of keys (as Count), of nodes in the node array (in use or not)
+//       and of nodes on the 'deleted' list waiting to be re-used
+//
+func Sizes(indexStructure *Index) (keyCount, nodeCount, deletedCount int) {
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	keyCount = indexStructure.keyCount
+	nodeCount = len(indexStructure.node)
+	for x := indexStructure.deletedRootPointer; x != nullIndexPointer; x = indexStructure.node[x].rightPointer {
+		deletedCount++
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// NewExpvarMetrics publishes an expvar map called 'name' holding, for each Operation, a map of "calls", "hits",
+//                  "misses", "latency_ns" and "lock_wait_ns" (totals) and "latency" (a histogram of LatencyBuckets)
+//                  along with "keys", "nodes" and "deleted" -- the Sizes of the index when they are read
+//                  pass it to SetMetrics to start measuring -- like expvar.Publish it panics if 'name' is in use
+//
+func NewExpvarMetrics(name string, indexStructure *Index) (metrics *ExpvarMetrics) {
+	metrics = &ExpvarMetrics{operationMaps: make(map[Operation]*expvar.Map)}
+	indexMap := expvar.NewMap(name)
+	for _, operation := range []Operation{
+		OperationInsert, OperationDelete, OperationSelect, OperationSearch, OperationScan, OperationRange,
+		OperationMatch, OperationRegexp, OperationFuzzy, OperationApply, OperationTransaction,
+	} {
+		operationMap := new(expvar.Map)
+		operationMap.Set("latency", new(expvar.Map))
+		indexMap.Set(string(operation), operationMap)
+		metrics.operationMaps[operation] = operationMap
+	}
+	indexMap.Set("keys", expvar.Func(func() any {
+		keyCount, _, _ := Sizes(indexStructure)
+		return keyCount
+	}))
+	indexMap.Set("nodes", expvar.Func(func() any {
+		_, nodeCount, _ := Sizes(indexStructure)
+		return nodeCount
+	}))
+	indexMap.Set("deleted", expvar.Func(func() any {
+		_, _, deletedCount := Sizes(indexStructure)
+		return deletedCount
+	}))
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// ObserveLockWait adds to the total time an operation has waited for the index
+//
+func (metrics *ExpvarMetrics) ObserveLockWait(operation Operation, lockWait time.Duration) {
+	if operationMap := metrics.operationMaps[operation]; operationMap != nil {
+		operationMap.Add("lock_wait_ns", int64(lockWait))
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// ObserveOperation counts an operation as a hit or miss and adds its latency to the total and the histogram
+//
+func (metrics *ExpvarMetrics) ObserveOperation(operation Operation, hit bool, latency time.Duration) {
+	operationMap := metrics.operationMaps[operation]
+	if operationMap == nil {
+		return
+	}
+	operationMap.Add("calls", 1)
+	if hit {
+		operationMap.Add("hits", 1)
+	} else {
+		operationMap.Add("misses", 1)
+	}
+	operationMap.Add("latency_ns", int64(latency))
+	operationMap.Get("latency").(*expvar.Map).Add(latencyBucket(latency), 1)
+}
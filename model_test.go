@@ -0,0 +1,346 @@
+package index
+
+import (
+	"maps"
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+type keyModel map[string][]int // what an index should hold -- each key's elements in order
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (model keyModel) insert(keyString string, keyElement int) (success bool) {
+	elementNumber, found := slices.BinarySearch(model[keyString], keyElement)
+	if found {
+		return
+	}
+	model[keyString] = slices.Insert(model[keyString], elementNumber, keyElement)
+	success = true
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (model keyModel) delete(keyString string, keyElement int) (success bool) {
+	elementNumber, found := slices.BinarySearch(model[keyString], keyElement)
+	if !found {
+		return
+	}
+	model[keyString] = slices.Delete(model[keyString], elementNumber, elementNumber+1)
+	if len(model[keyString]) == 0 {
+		delete(model, keyString)
+	}
+	success = true
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (model keyModel) entries() (entries []KeyEntry) {
+	for _, keyString := range slices.Sorted(maps.Keys(model)) {
+		entries = append(entries, KeyEntry{keyString, slices.Clone(model[keyString])})
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (model keyModel) count() (keyCount int) {
+	for _, keyElements := range model {
+		keyCount += len(keyElements)
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func sortedEntries(indexStructure *Index) (entries []KeyEntry) {
+	// every key in key order -- the duplicates branch holds elements in character order so they are sorted to compare
+	entries = allEntries(indexStructure)
+	for _, entry := range entries {
+		slices.Sort(entry.Elements)
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func checkModel(tb testing.TB, step string, indexStructure *Index, model keyModel) {
+	tb.Helper()
+	if err := Verify(indexStructure); err != nil {
+		tb.Fatalf("%s: %v", step, err)
+	}
+	if keyCount := Count(indexStructure); keyCount != model.count() {
+		tb.Fatalf("%s: Count returned %d, want %d", step, keyCount, model.count())
+	}
+	if entries := sortedEntries(indexStructure); !reflect.DeepEqual(entries, model.entries()) {
+		tb.Fatalf("%s: holds %v, want %v", step, entries, model.entries())
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func randomKey(random *rand.Rand) (keyString string) {
+	// one to four of "ab" -- few enough that keys are often repeated or a prefix of each other
+	for range 1 + random.IntN(4) {
+		keyString += string("ab"[random.IntN(2)])
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func randomChange(step string, random *rand.Rand, indexStructure *Index, model keyModel) (err string) {
+	// makes one random change to both the index and the model -- 'err' describes a result that differs
+	keyString, keyElement := randomKey(random), random.IntN(20)
+	switch random.IntN(4) {
+	case 0, 1:
+		want := model.insert(keyString, keyElement)
+		if success := Insert(keyString, keyElement, indexStructure); success != want {
+			err = step + ": Insert returned " + strconv.FormatBool(success)
+		}
+	case 2:
+		want := model.delete(keyString, keyElement)
+		if success := Delete(keyString, keyElement, indexStructure); success != want {
+			err = step + ": Delete returned " + strconv.FormatBool(success)
+		}
+	case 3:
+		keyElements := make([]int, random.IntN(6))
+		for i := range keyElements {
+			keyElements[i] = random.IntN(20) // repeats (in the call and of elements held) are allowed
+		}
+		want := 0
+		for _, keyElement := range keyElements {
+			if model.insert(keyString, keyElement) {
+				want++
+			}
+		}
+		if newCount := InsertMany(keyString, keyElements, indexStructure); newCount != want {
+			err = step + ": InsertMany returned " + strconv.Itoa(newCount) + ", want " + strconv.Itoa(want)
+		}
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestModel(t *testing.T) {
+	// random changes are made to an index and to a map -- after each the index must be sound and hold what the map
+	// does -- every so often a snapshot is taken which must still hold what the map did then at the end
+	for _, layout := range duplicateLayouts {
+		t.Run(layout.name, func(t *testing.T) {
+			random := rand.New(rand.NewPCG(1, 2))
+			indexStructure := newDuplicatesIndex(t, layout.postingLists, "", 0)
+			model := keyModel{}
+			type snapshotCheck struct {
+				snapshot *Index
+				entries  []KeyEntry
+			}
+			var snapshots []snapshotCheck
+			for stepNumber := range 3000 {
+				step := "step " + strconv.Itoa(stepNumber)
+				if err := randomChange(step, random, indexStructure, model); err != "" {
+					t.Fatal(err)
+				}
+				checkModel(t, step, indexStructure, model)
+				if stepNumber%250 == 0 {
+					snapshots = append(snapshots, snapshotCheck{Snapshot(indexStructure), model.entries()})
+				}
+			}
+			for i, check := range snapshots {
+				if Insert("a", 99, check.snapshot) || Delete("a", 99, check.snapshot) {
+					t.Errorf("snapshot %d: a change succeeded", i)
+				}
+				if err := Verify(check.snapshot); err != nil {
+					t.Errorf("snapshot %d: %v", i, err)
+				}
+				if entries := sortedEntries(check.snapshot); !reflect.DeepEqual(entries, check.entries) {
+					t.Errorf("snapshot %d: holds %v, want %v", i, entries, check.entries)
+				}
+			}
+		})
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestApplyModel(t *testing.T) {
+	// each batch's results must be those of making its changes to the map one at a time
+	for _, layout := range duplicateLayouts {
+		t.Run(layout.name, func(t *testing.T) {
+			random := rand.New(rand.NewPCG(3, 4))
+			indexStructure := newDuplicatesIndex(t, layout.postingLists, "", 0)
+			model := keyModel{}
+			var batch Batch
+			for batchNumber := range 300 {
+				step := "batch " + strconv.Itoa(batchNumber)
+				batch.Reset()
+				var want []bool
+				for range random.IntN(10) {
+					keyString, keyElement := randomKey(random), random.IntN(20)
+					if random.IntN(3) == 0 {
+						batch.Delete(keyString, keyElement)
+						want = append(want, model.delete(keyString, keyElement))
+					} else {
+						batch.Insert(keyString, keyElement)
+						want = append(want, model.insert(keyString, keyElement))
+					}
+				}
+				if results := Apply(indexStructure, &batch); !slices.Equal(results, want) {
+					t.Fatalf("%s: Apply returned %v, want %v", step, results, want)
+				}
+				checkModel(t, step, indexStructure, model)
+			}
+		})
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestBuildSortedModel(t *testing.T) {
+	// the built index must hold the same keys whatever order they are given in -- and carry on being sound as it is
+	// changed afterwards
+	random := rand.New(rand.NewPCG(5, 6))
+	model := keyModel{}
+	for range 200 {
+		model.insert(randomKey(random), random.IntN(20))
+	}
+	var inputEntries []sortedEntry
+	for _, entry := range model.entries() {
+		for _, keyElement := range entry.Elements {
+			inputEntries = append(inputEntries, sortedEntry{keyString: entry.Key, keyElement: keyElement})
+		}
+	}
+	tests := []struct {
+		name    string
+		reorder func(entries []sortedEntry)
+	}{
+		{"sorted", func(entries []sortedEntry) {}},
+		{"reversed", slices.Reverse[[]sortedEntry]},
+		{"shuffled", func(entries []sortedEntry) {
+			random.Shuffle(len(entries), func(i, j int) { entries[i], entries[j] = entries[j], entries[i] })
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			orderedEntries := slices.Clone(inputEntries)
+			test.reorder(orderedEntries)
+			indexStructure := BuildSorted(func(yield func(string, int) bool) {
+				for _, entry := range orderedEntries {
+					if !yield(entry.keyString, entry.keyElement) {
+						return
+					}
+				}
+			})
+			changedModel := maps.Clone(model)
+			for keyString, keyElements := range changedModel {
+				changedModel[keyString] = slices.Clone(keyElements)
+			}
+			checkModel(t, "built", indexStructure, changedModel)
+			for stepNumber := range 500 {
+				step := "step " + strconv.Itoa(stepNumber)
+				if err := randomChange(step, random, indexStructure, changedModel); err != "" {
+					t.Fatal(err)
+				}
+				checkModel(t, step, indexStructure, changedModel)
+			}
+		})
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestShardedIndexModel(t *testing.T) {
+	// the same changes are made to a sharded index and to a single index -- every query must return the same
+	// elements, in the same order for range shards (which keep key order) and the same set for hash shards
+	tests := []struct {
+		name             string
+		shardedStructure *ShardedIndex
+		inOrder          bool
+	}{
+		{"range", NewRangeShardedIndex([]string{"ab", "b", "ba", "bb"}), true},
+		{"hash", NewHashShardedIndex(4), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			random := rand.New(rand.NewPCG(7, 8))
+			indexStructure := newDuplicatesIndex(t, false, "", 0)
+			compare := func(step string, shardedSuccess bool, shardedResults []int, success bool, results []int) {
+				t.Helper()
+				if !test.inOrder {
+					slices.Sort(shardedResults)
+					slices.Sort(results)
+				}
+				if shardedSuccess != success || !slices.Equal(shardedResults, results) {
+					t.Fatalf("%s: got %v %v, want %v %v", step, shardedSuccess, shardedResults, success, results)
+				}
+			}
+			for stepNumber := range 2000 {
+				step := "step " + strconv.Itoa(stepNumber)
+				keyString, keyElement := randomKey(random), random.IntN(20)
+				if random.IntN(3) == 0 {
+					success := test.shardedStructure.Delete(keyString, keyElement)
+					compare(step+" Delete", success, nil, Delete(keyString, keyElement, indexStructure), nil)
+				} else {
+					success := test.shardedStructure.Insert(keyString, keyElement)
+					compare(step+" Insert", success, nil, Insert(keyString, keyElement, indexStructure), nil)
+				}
+				for i := range test.shardedStructure.shards {
+					if err := Verify(&test.shardedStructure.shards[i]); err != nil {
+						t.Fatalf("%s: shard %d: %v", step, i, err)
+					}
+				}
+				if keyCount := test.shardedStructure.Count(); keyCount != Count(indexStructure) {
+					t.Fatalf("%s: Count returned %d, want %d", step, keyCount, Count(indexStructure))
+				}
+				queryKey, otherKey := randomKey(random), randomKey(random)
+				shardedSuccess, shardedResults := test.shardedStructure.Select(queryKey)
+				success, results := Select(queryKey, indexStructure)
+				compare(step+" Select "+queryKey, shardedSuccess, shardedResults, success, results)
+				shardedSuccess, shardedResults = test.shardedStructure.Search(queryKey[:1])
+				success, results = Search(queryKey[:1], indexStructure)
+				compare(step+" Search "+queryKey[:1], shardedSuccess, shardedResults, success, results)
+				shardedSuccess, shardedResults = test.shardedStructure.Search(queryKey)
+				success, results = Search(queryKey, indexStructure)
+				compare(step+" Search "+queryKey, shardedSuccess, shardedResults, success, results)
+				shardedSuccess, shardedResults = test.shardedStructure.Range(queryKey, otherKey)
+				success, results = Range(queryKey, otherKey, indexStructure)
+				compare(step+" Range "+queryKey+" "+otherKey, shardedSuccess, shardedResults, success, results)
+				shardedSuccess, shardedResults = test.shardedStructure.Range(queryKey, "")
+				success, results = Range(queryKey, "", indexStructure)
+				compare(step+" Range "+queryKey+" open", shardedSuccess, shardedResults, success, results)
+				shardedSuccess, shardedResults = test.shardedStructure.Scan()
+				success, results = Scan(indexStructure)
+				compare(step+" Scan", shardedSuccess, shardedResults, success, results)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package index
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//  CONSTANTS and PSEUDO-CONSTANTS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+const savedIndexHeader = "IDX1" // starts every saved index -- the digit is the format version
+
+var (
+	// ErrCorrupt is returned when saved index (or log) content is damaged or isn't an index at all
+	ErrCorrupt = errors.New("index: saved content is corrupt")
+	// ErrNotEmpty is returned when an index must be empty (just initialised) but isn't
+	ErrNotEmpty = errors.New("index: index is not empty")
+	// ErrReadOnly is returned when an index can't be changed -- a snapshot
+	ErrReadOnly = errors.New("index: index is read-only")
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type checksumReader struct { // keeps a checksum of just the bytes that have been read (not those read ahead)
+	bufferedReader *bufio.Reader
+	checksum       hash.Hash32
+}
+
+func (contentReader *checksumReader) Read(buffer []byte) (byteCount int, err error) {
+	byteCount, err = contentReader.bufferedReader.Read(buffer)
+	contentReader.checksum.Write(buffer[:byteCount])
+	return
+}
+
+func (contentReader *checksumReader) ReadByte() (nextByte byte, err error) {
+	nextByte, err = contentReader.bufferedReader.ReadByte()
+	if err == nil {
+		contentReader.checksum.Write([]byte{nextByte})
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func saveIndex(writer io.Writer, indexStructure *Index) (err error) {
+	// writes every key (as stored) and its elements in key order followed by a checksum -- the index must be locked
+	// layout: header, then for each key: length, key bytes, element count, elements -- then a zero length, checksum
+	bufferedWriter := bufio.NewWriter(writer)
+	checksum := crc32.NewIEEE()
+	contentWriter := io.MultiWriter(bufferedWriter, checksum)
+	var numberBuffer [binary.MaxVarintLen64]byte
+	writeNumber := func(number int, signed bool) {
+		if err != nil {
+			return
+		}
+		numberLength := 0
+		if signed {
+			numberLength = binary.PutVarint(numberBuffer[:], int64(number))
+		} else {
+			numberLength = binary.PutUvarint(numberBuffer[:], uint64(number))
+		}
+		_, err = contentWriter.Write(numberBuffer[:numberLength])
+	}
+	_, err = io.WriteString(contentWriter, savedIndexHeader)
+	walker := keyWalker{indexStructure: indexStructure}
+	walker.keyVisit = func(keyString []byte, keyPointer int) bool {
+		keyElements := walker.collectElements(keyPointer)
+		writeNumber(len(keyString), false)
+		if err == nil {
+			_, err = contentWriter.Write(keyString)
+		}
+		writeNumber(len(keyElements), false)
+		for _, keyElement := range keyElements {
+			writeNumber(keyElement, true)
+		}
+		return err == nil
+	}
+	if err == nil {
+		walkKeys(indexStructure.indexRootPointer, nil, 0, 255, &walker)
+	}
+	writeNumber(0, false) // no more keys
+	if err != nil {
+		return
+	}
+	_, err = bufferedWriter.Write(checksum.Sum(nil))
+	if err != nil {
+		return
+	}
+	err = bufferedWriter.Flush()
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func loadIndex(reader io.Reader) (entries []sortedEntry, err error) {
+	// reads back the keys and elements written by 'saveIndex' -- in key order ready for 'buildIndex'
+	contentReader := &checksumReader{bufferedReader: bufio.NewReader(reader), checksum: crc32.NewIEEE()}
+	readNumber := func(signed bool) (number int) {
+		if err != nil {
+			return
+		}
+		if signed {
+			var signedNumber int64
+			signedNumber, err = binary.ReadVarint(contentReader)
+			number = int(signedNumber)
+		} else {
+			var unsignedNumber uint64
+			unsignedNumber, err = binary.ReadUvarint(contentReader)
+			number = int(unsignedNumber)
+			if unsignedNumber > uint64(1<<31) { // far more than could be real
+				err = ErrCorrupt
+			}
+		}
+		return
+	}
+	headerBuffer := make([]byte, len(savedIndexHeader))
+	_, err = io.ReadFull(contentReader, headerBuffer)
+	if err == nil && string(headerBuffer) != savedIndexHeader {
+		err = ErrCorrupt
+	}
+	keyBuffer := make([]byte, maxKeyLength)
+	previousKey := ""
+	for err == nil {
+		keyLength := readNumber(false)
+		if err != nil || keyLength == 0 { // no more keys (or a failure)
+			break
+		}
+		if keyLength > maxKeyLength {
+			err = ErrCorrupt
+			break
+		}
+		_, err = io.ReadFull(contentReader, keyBuffer[:keyLength])
+		keyString := string(keyBuffer[:keyLength])
+		if err == nil && keyString <= previousKey {
+			err = ErrCorrupt
+		}
+		previousKey = keyString
+		elementCount := readNumber(false)
+		for i := 0; i < elementCount && err == nil; i++ {
+			entries = append(entries, sortedEntry{keyString: keyString, keyElement: readNumber(true)})
+		}
+	}
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF { // cut short
+			err = ErrCorrupt
+		}
+		entries = nil
+		return
+	}
+	contentSum := contentReader.checksum.Sum32()
+	savedSum := make([]byte, crc32.Size)
+	_, err = io.ReadFull(contentReader.bufferedReader, savedSum)
+	if err != nil || binary.BigEndian.Uint32(savedSum) != contentSum {
+		entries = nil
+		err = ErrCorrupt
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Save writes the whole content of the index (every key and its "element" numbers) to the writer so that Load can
+//      rebuild it -- keys are written as they are stored (after any collation)
+//
+func Save(writer io.Writer, indexStructure *Index) (err error) {
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	err = saveIndex(writer, indexStructure)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Load replaces the content of the index with content written by Save -- the index should have the same collation
+//      (and posting list setting if it matters) as the one that was saved
+//      nothing is changed if the content can't be read or is corrupt -- otherwise any subscribers are dropped
+//
+func Load(reader io.Reader, indexStructure *Index) (err error) {
+	entries, err := loadIndex(reader)
+	if err != nil {
+		return
+	}
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	switch {
+	case indexStructure.readOnly:
+		err = ErrReadOnly
+	case indexStructure.writeAheadLog != nil: // the log would no longer match the content
+		err = ErrLogged
+	default:
+		buildIndex(entries, indexStructure)
+		contentReplaced(indexStructure)
+	}
+	return
+}
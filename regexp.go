@@ -0,0 +1,248 @@
+package index
+
+import (
+	"context"
+	"regexp"
+	"regexp/syntax"
+	"unicode/utf8"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type regexpState struct { // where the automaton is after a key prefix
+	threads      []uint32 // instructions reached by the last character -- their empty-width steps not yet taken
+	lastRune     rune     // -1 at the start of the key
+	partialRune  []byte   // the start of a multi-byte character still to be completed
+	matchedEarly bool     // a match has already been found -- every key starting with the prefix matches
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type regexpAutomaton struct {
+	program       *syntax.Prog
+	prefixStates  prefixMemo[regexpState] // [i] -- after i characters of a prefix
+	instructionAt []uint32                // when each instruction was last added to a set -- see 'followEmpty'
+	generation    uint32
+	runeThreads   []uint32 // the rune instructions found by 'followEmpty'
+	restartable   bool     // a match can start after the start of a key -- the automaton is then never dead
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (automaton *regexpAutomaton) followEmpty(threads []uint32, emptyContext syntax.EmptyOp) (matched bool) {
+	// takes every step that doesn't use up a character from the threads -- and from the start, as the expression can
+	// match anywhere in a key -- leaving the instructions that do in 'runeThreads'
+	// 'matched' is true if the end of the expression is reached
+	automaton.generation++
+	automaton.runeThreads = automaton.runeThreads[:0]
+	stack := append(append([]uint32(nil), threads...), uint32(automaton.program.Start))
+	for len(stack) > 0 {
+		pc := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if automaton.instructionAt[pc] == automaton.generation {
+			continue
+		}
+		automaton.instructionAt[pc] = automaton.generation
+		instruction := &automaton.program.Inst[pc]
+		switch instruction.Op {
+		case syntax.InstAlt, syntax.InstAltMatch:
+			stack = append(stack, instruction.Arg, instruction.Out)
+		case syntax.InstCapture, syntax.InstNop:
+			stack = append(stack, instruction.Out)
+		case syntax.InstEmptyWidth:
+			if syntax.EmptyOp(instruction.Arg)&^emptyContext == 0 {
+				stack = append(stack, instruction.Out)
+			}
+		case syntax.InstMatch:
+			matched = true
+		case syntax.InstRune, syntax.InstRune1, syntax.InstRuneAny, syntax.InstRuneAnyNotNL:
+			automaton.runeThreads = append(automaton.runeThreads, pc)
+		}
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func matchesRune(instruction *syntax.Inst, keyRune rune) bool {
+	switch instruction.Op {
+	case syntax.InstRuneAny:
+		return true
+	case syntax.InstRuneAnyNotNL:
+		return keyRune != '\n'
+	}
+	return instruction.MatchRune(keyRune)
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func matchesMultiByte(instruction *syntax.Inst) bool {
+	// could the instruction match some character that takes more than one byte? -- only ever too willing
+	switch instruction.Op {
+	case syntax.InstRuneAny, syntax.InstRuneAnyNotNL:
+		return true
+	}
+	if syntax.Flags(instruction.Arg)&syntax.FoldCase != 0 { // some letters fold to multi-byte ones
+		return true
+	}
+	return len(instruction.Rune) > 0 && instruction.Rune[len(instruction.Rune)-1] >= utf8.RuneSelf
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (automaton *regexpAutomaton) stepRune(state *regexpState, keyRune rune) {
+	// moves the automaton on by one (whole) character of the key
+	if automaton.followEmpty(state.threads, syntax.EmptyOpContext(state.lastRune, keyRune)) {
+		state.matchedEarly = true
+	}
+	var nextThreads []uint32
+	for _, pc := range automaton.runeThreads {
+		if matchesRune(&automaton.program.Inst[pc], keyRune) {
+			nextThreads = append(nextThreads, automaton.program.Inst[pc].Out)
+		}
+	}
+	state.threads = nextThreads
+	state.lastRune = keyRune
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (automaton *regexpAutomaton) stepByte(state regexpState, keyCharacter byte) (nextState regexpState) {
+	// moves the automaton on by one byte of the key -- a character is only used once all its bytes are there and
+	// bytes that can't make a character are each taken as utf8.RuneError (as the regexp package does)
+	nextState = state
+	nextState.partialRune = append(append([]byte(nil), state.partialRune...), keyCharacter)
+	if nextState.matchedEarly {
+		return
+	}
+	for len(nextState.partialRune) > 0 && utf8.FullRune(nextState.partialRune) {
+		keyRune, runeLength := utf8.DecodeRune(nextState.partialRune)
+		automaton.stepRune(&nextState, keyRune)
+		nextState.partialRune = nextState.partialRune[runeLength:]
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (automaton *regexpAutomaton) keyFilter(keyPrefix []byte, lowCharacter, highCharacter byte) bool {
+	// a 'keyWalker' filter that skips the branches where the automaton is dead -- no key in them can match
+	if automaton.restartable {
+		return true
+	}
+	state := stateAfter(keyPrefix, &automaton.prefixStates)
+	if state.matchedEarly || len(state.partialRune) > 0 { // part way through a character it is never judged dead
+		return true
+	}
+	// there is a next character but which is unknown -- so assume a word boundary test of it passes either way
+	emptyContext := syntax.EmptyOpContext(state.lastRune, -1)&^(syntax.EmptyEndText|syntax.EmptyEndLine) |
+		syntax.EmptyWordBoundary | syntax.EmptyNoWordBoundary
+	if lowCharacter <= '\n' && highCharacter >= '\n' {
+		emptyContext |= syntax.EmptyEndLine
+	}
+	if automaton.followEmpty(state.threads, emptyContext) {
+		return true
+	}
+	for _, pc := range automaton.runeThreads {
+		instruction := &automaton.program.Inst[pc]
+		for c := int(lowCharacter); c <= int(highCharacter); c++ {
+			if c >= utf8.RuneSelf {
+				if matchesMultiByte(instruction) {
+					return true
+				}
+				break
+			}
+			if matchesRune(instruction, rune(c)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (automaton *regexpAutomaton) matches(keyString []byte) bool {
+	// does the expression match the whole of a key? -- finishing off any incomplete character as utf8.RuneError
+	state := stateAfter(keyString, &automaton.prefixStates)
+	if state.matchedEarly {
+		return true
+	}
+	for range state.partialRune {
+		automaton.stepRune(&state, utf8.RuneError)
+	}
+	if state.matchedEarly {
+		return true
+	}
+	return automaton.followEmpty(state.threads, syntax.EmptyOpContext(state.lastRune, -1))
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// SearchRegexp returns every key (as stored) that the regular expression matches, with its Element numbers, in key
+//              order -- a key matches as for re.Match, anywhere in the key unless the expression is anchored
+//              the expression is run as an automaton alongside the walk through the index and a branch is left as
+//              soon as no key in it can match -- only possible when the expression is anchored at the start of the
+//              key ("^ab.*d") -- otherwise every key is looked at
+//              'success' is true if at least one key matches -- 'err' is only set if 're' can't be compiled again
+//
+func SearchRegexp(re *regexp.Regexp, indexStructure *Index) (success bool, entries []KeyEntry, err error) {
+	timer := lockIndex(context.Background(), OperationRegexp, len(re.String()), indexStructure)
+	defer func() { unlockIndex(timer, success, len(entries), indexStructure) }()
+	//
+	expression, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return
+	}
+	program, err := syntax.Compile(expression.Simplify())
+	if err != nil {
+		return
+	}
+	automaton := regexpAutomaton{program: program, instructionAt: make([]uint32, len(program.Inst))}
+	automaton.prefixStates = newPrefixMemo(regexpState{lastRune: -1}, automaton.stepByte)
+	automaton.restartable = automaton.followEmpty(nil, syntax.EmptyBeginLine|syntax.EmptyEndLine|syntax.EmptyEndText|
+		syntax.EmptyWordBoundary|syntax.EmptyNoWordBoundary) || len(automaton.runeThreads) > 0
+	walker := keyWalker{indexStructure: indexStructure, keyFilter: automaton.keyFilter}
+	walker.keyVisit = func(keyString []byte, keyPointer int) bool {
+		if automaton.matches(keyString) {
+			entries = append(entries, KeyEntry{string(keyString), walker.collectElements(keyPointer)})
+		}
+		return true
+	}
+	walkKeys(indexStructure.indexRootPointer, nil, 0, 255, &walker)
+	timer.nodesVisited = walker.nodesVisited
+	success = len(entries) > 0
+	return
+}
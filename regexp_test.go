@@ -0,0 +1,35 @@
+package index
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestSearchRegexp(t *testing.T) {
+	expressions := []string{
+		"", "^a", "^ab.*c$", "b", "c$", "^[a-c]+$", "^[^a]", "^.{3}$", "^(ab|ba)c", "^a*$", "^1|c$", "x",
+		"(?i)^k", "(?i)s", "^é", "^\\p{L}+$", "^�", "\\bb", "\\Bc", "^a\\b", "(?m)^b", "(?s)^a.b", "c\\z", "^$",
+		"^a\\*c$", "^\\[",
+	}
+	for _, layout := range duplicateLayouts {
+		indexStructure := newFilterIndex(t, layout.postingLists)
+		// keys that aren't plain ASCII text -- several runes, invalid UTF-8, case folding and line breaks
+		for keyElement, keyString := range []string{"é", "éa", "\xffa", "a\xe2\x82", "K", "ſ", "a b", "a\nb", "1c"} {
+			Insert(keyString, 2000+keyElement, indexStructure)
+		}
+		for _, expression := range expressions {
+			t.Run(layout.name+"/"+expression, func(t *testing.T) {
+				re := regexp.MustCompile(expression)
+				want := filterEntries(indexStructure, re.MatchString)
+				success, entries, err := SearchRegexp(re, indexStructure)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if success != (len(want) > 0) || !reflect.DeepEqual(entries, want) {
+					t.Errorf("got %v %q, want %q", success, entries, want)
+				}
+			})
+		}
+	}
+}
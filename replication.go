@@ -0,0 +1,495 @@
+package index
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//  CONSTANTS and PSEUDO-CONSTANTS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+const (
+	helloMessage    = 'H' // follower to primary -- how far it has got
+	snapshotMessage = 'S' // primary to follower -- the whole content
+	eventsMessage   = 'E' // primary to follower -- the changes that follow on
+	ackMessage      = 'A' // follower to primary -- how far it has now got
+	//
+	defaultBacklogLength = 65536 // changes a primary keeps so that followers can carry on after reconnecting
+	eventsPerMessage     = 256
+)
+
+var (
+	// ErrFollowerBehind is returned by Serve when a follower falls too far behind the changes to the index
+	ErrFollowerBehind = errors.New("index: follower fell too far behind")
+	// ErrProtocol is returned when a replication message isn't what was expected
+	ErrProtocol = errors.New("index: unexpected replication message")
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type replicationMessage struct {
+	Kind     byte
+	Epoch    uint64 // which primary (and run of sequence numbers) a snapshot came from
+	Seq      uint64 // the change the follower is up to once it has dealt with the message
+	Latest   uint64 // the last change made to the primary when the message was sent
+	Snapshot []byte
+	Events   []Event
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// FollowerStatus is how far one follower connected to a primary has got
+//
+type FollowerStatus struct {
+	Follower     int    // numbered in the order they connected
+	Acknowledged uint64 // the last change the follower has applied
+	Lag          uint64 // how many changes the follower is behind
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Primary sends the changes made to an index to followers (each a Replica, usually in another process) -- see
+//         NewPrimary
+//
+type Primary struct {
+	indexStructure *Index
+	epoch          uint64
+	observer       *changeObserver
+	backlog        []Event // the most recent changes -- only used with the index locked
+	backlogLength  int
+	latestSequence atomic.Uint64
+	followerMutex  sync.Mutex
+	followers      map[int]*FollowerStatus
+	followerCount  int
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Replica keeps an index up to date with the index of a Primary -- see NewReplica
+//
+type Replica struct {
+	indexStructure   *Index
+	epoch            uint64
+	appliedSequence  atomic.Uint64
+	primarySequence  atomic.Uint64
+	followInProgress atomic.Bool
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func observeForBacklog(event Event, primary *Primary) {
+	// keeps the last 'backlogLength' changes (at least) -- the index is locked
+	if event.Op == 0 { // the content was replaced -- followers can't carry on from before it
+		primary.backlog = primary.backlog[:0]
+	} else {
+		if len(primary.backlog) == 2*primary.backlogLength { // drop the oldest half
+			copy(primary.backlog, primary.backlog[primary.backlogLength:])
+			primary.backlog = primary.backlog[:primary.backlogLength]
+		}
+		primary.backlog = append(primary.backlog, event)
+	}
+	primary.latestSequence.Store(event.Seq)
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func backlogSince(sentSequence uint64, primary *Primary) (missedEvents []Event, found bool) {
+	// copies the changes after 'sentSequence' from the backlog -- if it reaches back that far (the index is locked)
+	backlog := primary.backlog
+	if len(backlog) > 0 && backlog[0].Seq <= sentSequence+1 && sentSequence < backlog[len(backlog)-1].Seq {
+		missedEvents = append([]Event(nil), backlog[sentSequence+1-backlog[0].Seq:]...)
+		found = true
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func startFollower(hello replicationMessage, primary *Primary) (snapshot *Index, missedEvents []Event,
+	eventChannel chan Event) {
+	// works out what a follower needs to catch up -- the changes it has missed (if they are all in the backlog) or
+	// a snapshot -- and subscribes it to every change after that
+	indexStructure := primary.indexStructure
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	latestSequence := indexStructure.changeSequence
+	found := false
+	switch {
+	case hello.Epoch != primary.epoch || hello.Seq > latestSequence: // following something else
+	case hello.Seq == latestSequence: // up to date
+		found = true
+	default:
+		missedEvents, found = backlogSince(hello.Seq, primary)
+	}
+	if !found { // missed too much
+		snapshot = takeSnapshot(indexStructure)
+		snapshot.changeSequence = latestSequence
+	}
+	eventChannel = subscribe(indexStructure)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func resumeFollower(sentSequence uint64, primary *Primary) (missedEvents []Event, eventChannel chan Event,
+	resumed bool) {
+	// subscribes a follower again after it was dropped for falling behind -- as long as the changes since the last
+	// one sent to it are all in the backlog (as when a big snapshot took a long time to send)
+	indexStructure := primary.indexStructure
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	if sentSequence == indexStructure.changeSequence {
+		resumed = true
+	} else {
+		missedEvents, resumed = backlogSince(sentSequence, primary)
+	}
+	if resumed {
+		eventChannel = subscribe(indexStructure)
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func sendEvents(events []Event, encoder *gob.Encoder, primary *Primary) (err error) {
+	// sends changes to a follower -- a limited number to each message
+	for len(events) > 0 {
+		messageEvents := events[:min(len(events), eventsPerMessage)]
+		events = events[len(messageEvents):]
+		err = encoder.Encode(replicationMessage{
+			Kind:   eventsMessage,
+			Seq:    messageEvents[len(messageEvents)-1].Seq,
+			Latest: primary.latestSequence.Load(),
+			Events: messageEvents,
+		})
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func applyEvents(events []Event, replica *Replica) (err error) {
+	// makes the changes sent by the primary to the replica's index -- they must follow on exactly
+	records := make([]logRecord, len(events))
+	nextSequence := replica.appliedSequence.Load() + 1
+	for i, event := range events {
+		if event.Seq != nextSequence || (event.Op != EventInsert && event.Op != EventDelete) ||
+			len(event.Key) == 0 || len(event.Key) > maxKeyLength {
+			err = ErrProtocol
+			return
+		}
+		records[i] = logRecord{byte(event.Op), event.Key, event.Element}
+		nextSequence++
+	}
+	indexStructure := replica.indexStructure
+	indexStructure.indexMutex.Lock()
+	applyRecords(records, indexStructure)
+	indexStructure.indexMutex.Unlock()
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// NewPrimary starts keeping track of the changes made to an index so they can be sent to followers (with Serve)
+//            'backlogLength' is how many recent changes are kept for followers that reconnect (zero for a default)
+//            -- a follower that has missed more than that is sent a snapshot of the whole index instead
+//
+func NewPrimary(indexStructure *Index, backlogLength int) (primary *Primary) {
+	if backlogLength <= 0 {
+		backlogLength = defaultBacklogLength
+	}
+	primary = &Primary{
+		indexStructure: indexStructure,
+		epoch:          rand.Uint64() | 1, // never zero -- a follower that hasn't followed anything yet
+		backlogLength:  backlogLength,
+		followers:      make(map[int]*FollowerStatus),
+	}
+	primary.observer = &changeObserver{observeChange: func(event Event) { observeForBacklog(event, primary) }}
+	//
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	indexStructure.changeObservers = append(indexStructure.changeObservers, primary.observer)
+	primary.latestSequence.Store(indexStructure.changeSequence)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Serve sends changes to one follower over the connection (a net.Conn or anything that can be read and written)
+//       until the connection fails or the follower falls too far behind -- the follower first says how far it has
+//       got and is sent the changes it missed (or a snapshot) then every change as it is made
+//       a follower that falls behind (while a big snapshot is sent, say) carries on from the backlog -- only once
+//       it has missed more than the backlog holds (or the whole content is replaced) is ErrFollowerBehind returned
+//       the caller should close the connection once Serve returns
+//
+func (primary *Primary) Serve(connection io.ReadWriter) (err error) {
+	encoder := gob.NewEncoder(connection)
+	decoder := gob.NewDecoder(connection)
+	var hello replicationMessage
+	err = decoder.Decode(&hello)
+	if err == nil && hello.Kind != helloMessage {
+		err = ErrProtocol
+	}
+	if err != nil {
+		return
+	}
+	snapshot, missedEvents, eventChannel := startFollower(hello, primary)
+	defer func() { Unsubscribe(eventChannel, primary.indexStructure) }() // the last channel subscribed
+	//
+	primary.followerMutex.Lock()
+	primary.followerCount++
+	followerStatus := &FollowerStatus{Follower: primary.followerCount, Acknowledged: hello.Seq}
+	primary.followers[followerStatus.Follower] = followerStatus
+	primary.followerMutex.Unlock()
+	defer func() {
+		primary.followerMutex.Lock()
+		delete(primary.followers, followerStatus.Follower)
+		primary.followerMutex.Unlock()
+	}()
+	//
+	ackFailure := make(chan error, 1)
+	go func() { // acknowledgements come back while changes are being sent
+		for {
+			var ack replicationMessage
+			if decodeErr := decoder.Decode(&ack); decodeErr != nil {
+				ackFailure <- decodeErr
+				return
+			}
+			if ack.Kind != ackMessage {
+				ackFailure <- ErrProtocol
+				return
+			}
+			primary.followerMutex.Lock()
+			followerStatus.Acknowledged = ack.Seq
+			primary.followerMutex.Unlock()
+		}
+	}()
+	sentSequence := hello.Seq
+	if snapshot != nil {
+		sentSequence = snapshot.changeSequence
+		var snapshotBuffer bytes.Buffer
+		err = saveIndex(&snapshotBuffer, snapshot)
+		if err == nil {
+			err = encoder.Encode(replicationMessage{
+				Kind:     snapshotMessage,
+				Epoch:    primary.epoch,
+				Seq:      snapshot.changeSequence,
+				Latest:   primary.latestSequence.Load(),
+				Snapshot: snapshotBuffer.Bytes(),
+			})
+		}
+	}
+	//
+	events := make([]Event, 0, eventsPerMessage)
+	for err == nil {
+		if len(missedEvents) > 0 {
+			err = sendEvents(missedEvents, encoder, primary)
+			sentSequence = missedEvents[len(missedEvents)-1].Seq
+			missedEvents = nil
+			continue
+		}
+		select {
+		case err = <-ackFailure:
+		case event, open := <-eventChannel:
+			if !open { // dropped -- or the whole content was replaced -- carry on from the backlog if it can
+				resumed := false
+				missedEvents, eventChannel, resumed = resumeFollower(sentSequence, primary)
+				if !resumed {
+					err = ErrFollowerBehind
+				}
+				continue
+			}
+			events = append(events[:0], event)
+			for len(events) < eventsPerMessage && len(eventChannel) > 0 { // send whatever else is waiting with it
+				events = append(events, <-eventChannel)
+			}
+			err = sendEvents(events, encoder, primary)
+			sentSequence = events[len(events)-1].Seq
+		}
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Followers returns how far each follower currently connected has got
+//
+func (primary *Primary) Followers() (followers []FollowerStatus) {
+	latestSequence := primary.latestSequence.Load()
+	primary.followerMutex.Lock()
+	defer primary.followerMutex.Unlock()
+	//
+	for _, followerStatus := range primary.followers {
+		follower := *followerStatus
+		if follower.Acknowledged < latestSequence {
+			follower.Lag = latestSequence - follower.Acknowledged
+		}
+		followers = append(followers, follower)
+	}
+	sort.Slice(followers, func(i, j int) bool { return followers[i].Follower < followers[j].Follower })
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Close stops keeping track of the changes made to the index -- Serve carries on for followers already connected
+//       but those that reconnect are sent a snapshot
+//
+func (primary *Primary) Close() {
+	indexStructure := primary.indexStructure
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	for i, observer := range indexStructure.changeObservers {
+		if observer == primary.observer {
+			indexStructure.changeObservers = append(indexStructure.changeObservers[:i],
+				indexStructure.changeObservers[i+1:]...)
+			break
+		}
+	}
+	primary.backlog = nil
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// NewReplica returns a follower that keeps the index (which should be empty) up to date with a primary -- the index
+//            should have the same collation (and posting list setting) as the primary's and only be read from
+//
+func NewReplica(indexStructure *Index) (replica *Replica) {
+	replica = &Replica{indexStructure: indexStructure}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Follow connects to a primary's Serve over the connection and applies its changes to the index until the
+//        connection fails -- it can then be called again (on a new connection) to carry on from where it got to
+//        only one Follow can be in progress at a time -- a second returns ErrProtocol
+//
+func (replica *Replica) Follow(connection io.ReadWriter) (err error) {
+	if !replica.followInProgress.CompareAndSwap(false, true) {
+		err = ErrProtocol
+		return
+	}
+	defer replica.followInProgress.Store(false)
+	//
+	encoder := gob.NewEncoder(connection)
+	decoder := gob.NewDecoder(connection)
+	err = encoder.Encode(replicationMessage{
+		Kind:  helloMessage,
+		Epoch: replica.epoch,
+		Seq:   replica.appliedSequence.Load(),
+	})
+	for err == nil {
+		var message replicationMessage
+		err = decoder.Decode(&message)
+		if err != nil {
+			return
+		}
+		switch message.Kind {
+		case snapshotMessage:
+			err = Load(bytes.NewReader(message.Snapshot), replica.indexStructure)
+			if err == nil {
+				replica.epoch = message.Epoch
+			}
+		case eventsMessage:
+			err = applyEvents(message.Events, replica)
+		default:
+			err = ErrProtocol
+		}
+		if err != nil {
+			return
+		}
+		replica.appliedSequence.Store(message.Seq)
+		replica.primarySequence.Store(max(message.Latest, message.Seq))
+		err = encoder.Encode(replicationMessage{Kind: ackMessage, Seq: message.Seq})
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Applied returns the sequence number (see Event) of the last of the primary's changes applied to the index
+//
+func (replica *Replica) Applied() (appliedSequence uint64) {
+	appliedSequence = replica.appliedSequence.Load()
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Lag returns how many changes the index was behind the primary when it last heard from it
+//
+func (replica *Replica) Lag() (lag uint64) {
+	appliedSequence := replica.appliedSequence.Load()
+	primarySequence := replica.primarySequence.Load()
+	if primarySequence > appliedSequence {
+		lag = primarySequence - appliedSequence
+	}
+	return
+}
@@ -0,0 +1,197 @@
+package index
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func followUntilCaughtUp(t *testing.T, primary *Primary, replica *Replica) {
+	// connects the replica to the primary until it has applied every change the primary has made -- then disconnects
+	t.Helper()
+	primaryConnection, replicaConnection := net.Pipe()
+	serveDone := make(chan error, 1)
+	followDone := make(chan error, 1)
+	go func() { serveDone <- primary.Serve(primaryConnection) }()
+	go func() { followDone <- replica.Follow(replicaConnection) }()
+	defer func() {
+		primaryConnection.Close()
+		replicaConnection.Close()
+		<-serveDone
+		<-followDone
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for replica.Applied() != primary.latestSequence.Load() {
+		if time.Now().After(deadline) {
+			t.Fatalf("the replica applied up to %d, want %d", replica.Applied(), primary.latestSequence.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func insertKeys(prefix string, keyCount int, indexStructure *Index) {
+	for i := range keyCount {
+		Insert(prefix+strconv.Itoa(i), i, indexStructure)
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestReplicaCatchUp(t *testing.T) {
+	// the replica follows the primary once (taking a snapshot) and is disconnected -- then changes are made and it
+	// reconnects to the primary returned (the same one or another) and must end up holding the same keys -- sent
+	// the changes it missed from the backlog if it can, otherwise a snapshot (which drops the replica's subscribers)
+	const backlogLength = 10
+	tests := []struct {
+		name     string
+		changes  func(primaryStructure *Index, primary *Primary) (*Index, *Primary)
+		snapshot bool
+	}{
+		{"up to date", func(primaryStructure *Index, primary *Primary) (*Index, *Primary) {
+			return primaryStructure, primary
+		}, false},
+		{"from the backlog", func(primaryStructure *Index, primary *Primary) (*Index, *Primary) {
+			insertKeys("b", 3, primaryStructure)
+			Delete("a0", 0, primaryStructure)
+			return primaryStructure, primary
+		}, false},
+		{"the whole backlog", func(primaryStructure *Index, primary *Primary) (*Index, *Primary) {
+			insertKeys("b", backlogLength, primaryStructure)
+			return primaryStructure, primary
+		}, false},
+		{"missed too much", func(primaryStructure *Index, primary *Primary) (*Index, *Primary) {
+			insertKeys("b", 2*backlogLength+1, primaryStructure)
+			return primaryStructure, primary
+		}, true},
+		{"content replaced", func(primaryStructure *Index, primary *Primary) (*Index, *Primary) {
+			var otherStructure Index
+			Initialise(&otherStructure)
+			insertKeys("c", 2, &otherStructure)
+			var savedContent bytes.Buffer
+			Save(&savedContent, &otherStructure)
+			Load(&savedContent, primaryStructure)
+			Insert("d", 1, primaryStructure)
+			return primaryStructure, primary
+		}, true},
+		{"new epoch on the same index", func(primaryStructure *Index, primary *Primary) (*Index, *Primary) {
+			// as after the primary restarts -- it has no record of what the replica was sent
+			primary.Close()
+			primary = NewPrimary(primaryStructure, backlogLength)
+			insertKeys("b", 2, primaryStructure)
+			return primaryStructure, primary
+		}, true},
+		{"new epoch further on", func(primaryStructure *Index, primary *Primary) (*Index, *Primary) {
+			// another index that has made more changes than the replica has applied -- its backlog reaches back to
+			// the replica's sequence number but the changes in it don't follow on from what the replica holds
+			otherStructure := new(Index)
+			Initialise(otherStructure)
+			primary = NewPrimary(otherStructure, backlogLength)
+			insertKeys("c", 8, otherStructure)
+			return otherStructure, primary
+		}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			primaryStructure := new(Index)
+			Initialise(primaryStructure)
+			insertKeys("a", 5, primaryStructure)
+			primary := NewPrimary(primaryStructure, backlogLength)
+			var replicaStructure Index
+			Initialise(&replicaStructure)
+			replica := NewReplica(&replicaStructure)
+			followUntilCaughtUp(t, primary, replica)
+			primaryStructure, primary = test.changes(primaryStructure, primary)
+			defer primary.Close()
+			replicaChannel := Subscribe(&replicaStructure)
+			followUntilCaughtUp(t, primary, replica)
+			replicated, want := allEntries(&replicaStructure), allEntries(primaryStructure)
+			if !reflect.DeepEqual(replicated, want) {
+				t.Errorf("the replica holds %v, want %v", replicated, want)
+			}
+			if _, snapshot := drainEvents(replicaChannel); snapshot != test.snapshot {
+				t.Errorf("sent a snapshot %v, want %v", snapshot, test.snapshot)
+			}
+			if replica.Lag() != 0 {
+				t.Errorf("the replica lags by %d", replica.Lag())
+			}
+		})
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type gatedConnection struct { // a connection that reads nothing until the gate is opened
+	net.Conn
+	gate chan struct{}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (connection gatedConnection) Read(buffer []byte) (int, error) {
+	<-connection.gate
+	return connection.Conn.Read(buffer)
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestReplicaWritesDuringSnapshot(t *testing.T) {
+	// the replica doesn't read the snapshot until far more changes have been made than a subscriber can fall behind
+	// by -- Serve must carry on from the backlog (not drop the follower) and the replica catch up on one connection
+	primaryStructure := new(Index)
+	Initialise(primaryStructure)
+	insertKeys("a", 1000, primaryStructure)
+	primary := NewPrimary(primaryStructure, 0)
+	defer primary.Close()
+	var replicaStructure Index
+	Initialise(&replicaStructure)
+	replica := NewReplica(&replicaStructure)
+	primaryConnection, replicaConnection := net.Pipe()
+	gate := make(chan struct{})
+	serveDone := make(chan error, 1)
+	followDone := make(chan error, 1)
+	go func() { serveDone <- primary.Serve(primaryConnection) }()
+	go func() { followDone <- replica.Follow(gatedConnection{replicaConnection, gate}) }()
+	for len(primary.Followers()) == 0 { // subscribed and sending the snapshot
+		time.Sleep(time.Millisecond)
+	}
+	insertKeys("b", 4*subscriptionBufferLength, primaryStructure)
+	close(gate)
+	go insertKeys("c", 2*subscriptionBufferLength, primaryStructure) // ... and carry on while it catches up
+	deadline := time.Now().Add(5 * time.Second)
+	for replica.Applied() != uint64(1000+6*subscriptionBufferLength) {
+		select {
+		case err := <-serveDone:
+			t.Fatalf("Serve returned %v before the replica caught up", err)
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the replica applied up to %d", replica.Applied())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	primaryConnection.Close()
+	replicaConnection.Close()
+	if err := <-serveDone; err == ErrFollowerBehind {
+		t.Errorf("Serve returned %v", err)
+	}
+	<-followDone
+	replicated, want := allEntries(&replicaStructure), allEntries(primaryStructure)
+	if !reflect.DeepEqual(replicated, want) {
+		t.Errorf("the replica holds %d keys, want %d", len(replicated), len(want))
+	}
+}
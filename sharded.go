@@ -0,0 +1,250 @@
+package index
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// ShardedIndex spreads its keys over several separate indexes (shards) each with its own lock -- so writes to
+//              different shards don't wait for each other
+//              keys are either split into ranges by a set of boundary keys (the shards stay in key order so results
+//              from several shards are still in key order) or by a hash of the key (writes spread more evenly but
+//              results are only in key order within each shard)
+//              queries covering several shards lock them one at a time -- they don't see a single moment across them
+//
+type ShardedIndex struct {
+	shards          []Index
+	shardBoundaries []string // shard 'i' holds the keys from boundary 'i-1' up to (not including) boundary 'i'
+	hashed          bool
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func shardOf(keyString string, shardedStructure *ShardedIndex) (shardNumber int) {
+	// finds the shard that holds (or would hold) a validated key
+	if shardedStructure.hashed {
+		keyHash := fnv.New32a()
+		keyHash.Write([]byte(keyString))
+		shardNumber = int(keyHash.Sum32() % uint32(len(shardedStructure.shards)))
+		return
+	}
+	shardNumber = sort.Search(len(shardedStructure.shardBoundaries), func(i int) bool {
+		return shardedStructure.shardBoundaries[i] > keyString
+	})
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func shardMayHold(shardNumber int, fromString, toString, keyPrefix string, shardedStructure *ShardedIndex) bool {
+	// checks whether a (range partitioned) shard could hold keys from 'fromString' up to 'toString' (either may be
+	// empty for an open end) that also start with 'keyPrefix'
+	if shardedStructure.hashed {
+		return true
+	}
+	if shardNumber < len(shardedStructure.shardBoundaries) { // the shard's keys are all below its boundary
+		upperBoundary := shardedStructure.shardBoundaries[shardNumber]
+		if upperBoundary <= fromString || upperBoundary <= keyPrefix {
+			return false
+		}
+	}
+	if shardNumber > 0 { // ... and at or above the one before
+		lowerBoundary := shardedStructure.shardBoundaries[shardNumber-1]
+		if toString != "" && lowerBoundary > toString {
+			return false
+		}
+		if lowerBoundary > keyPrefix && !strings.HasPrefix(lowerBoundary, keyPrefix) {
+			return false
+		}
+	}
+	return true
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// NewRangeShardedIndex returns an empty sharded index split into key ranges at each of the boundary keys -- there is
+//                      one more shard than there are (distinct) boundaries
+//
+func NewRangeShardedIndex(boundaryKeys []string) (shardedStructure *ShardedIndex) {
+	shardedStructure = new(ShardedIndex)
+	for _, boundaryKey := range boundaryKeys {
+		boundaryString, boundaryLength := validate(boundaryKey, nil)
+		if boundaryLength > 0 {
+			shardedStructure.shardBoundaries = append(shardedStructure.shardBoundaries, boundaryString)
+		}
+	}
+	sort.Strings(shardedStructure.shardBoundaries)
+	uniqueCount := 0
+	for i, boundaryString := range shardedStructure.shardBoundaries { // drop any repeats
+		if i == 0 || boundaryString != shardedStructure.shardBoundaries[uniqueCount-1] {
+			shardedStructure.shardBoundaries[uniqueCount] = boundaryString
+			uniqueCount++
+		}
+	}
+	shardedStructure.shardBoundaries = shardedStructure.shardBoundaries[:uniqueCount]
+	shardedStructure.shards = make([]Index, uniqueCount+1)
+	for i := range shardedStructure.shards {
+		Initialise(&shardedStructure.shards[i])
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// NewHashShardedIndex returns an empty sharded index with keys spread over the number of shards by a hash of each
+//                     key -- there is always at least one shard
+//
+func NewHashShardedIndex(shardCount int) (shardedStructure *ShardedIndex) {
+	if shardCount < 1 {
+		shardCount = 1
+	}
+	shardedStructure = &ShardedIndex{shards: make([]Index, shardCount), hashed: true}
+	for i := range shardedStructure.shards {
+		Initialise(&shardedStructure.shards[i])
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Insert places the input string into the index along with the supplied "element" number -- as for Insert
+//
+func (shardedStructure *ShardedIndex) Insert(keyInput string, keyElement int) (success bool) {
+	keyString, keyLength := validate(keyInput, nil)
+	if keyLength == 0 { // nothing to look for
+		return
+	}
+	success = Insert(keyString, keyElement, &shardedStructure.shards[shardOf(keyString, shardedStructure)])
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Delete removes a key and its associated "element" number from the index -- as for Delete
+//
+func (shardedStructure *ShardedIndex) Delete(keyInput string, keyElement int) (success bool) {
+	keyString, keyLength := validate(keyInput, nil)
+	if keyLength == 0 { // nothing to look for
+		return
+	}
+	success = Delete(keyString, keyElement, &shardedStructure.shards[shardOf(keyString, shardedStructure)])
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Select returns an array of zero or many Element numbers for the input key -- as for Select
+//
+func (shardedStructure *ShardedIndex) Select(keyInput string) (success bool, results []int) {
+	keyString, keyLength := validate(keyInput, nil)
+	if keyLength == 0 { // nothing to look for
+		return
+	}
+	success, results = Select(keyString, &shardedStructure.shards[shardOf(keyString, shardedStructure)])
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Search returns an array of zero or many Element numbers for every key starting with the input -- as for Search
+//        (in key order across the shards if they are split into ranges)
+//
+func (shardedStructure *ShardedIndex) Search(keyInput string) (success bool, results []int) {
+	keyString, keyLength := validate(keyInput, nil)
+	if keyLength == 0 { // nothing to look for
+		return
+	}
+	for i := range shardedStructure.shards {
+		if shardMayHold(i, "", "", keyString, shardedStructure) {
+			_, shardResults := Search(keyString, &shardedStructure.shards[i])
+			results = append(results, shardResults...)
+		}
+	}
+	success = len(results) > 0
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Range returns an array of zero or many Element numbers for every key from the first input string up to and
+//       including the second -- as for Range (in key order across the shards if they are split into ranges)
+//
+func (shardedStructure *ShardedIndex) Range(fromInput, toInput string) (success bool, results []int) {
+	fromString, _ := validate(fromInput, nil)
+	toString, _ := validate(toInput, nil)
+	if toString != "" && fromString > toString { // nothing can be in the range
+		return
+	}
+	for i := range shardedStructure.shards {
+		if shardMayHold(i, fromString, toString, "", shardedStructure) {
+			_, shardResults := Range(fromString, toString, &shardedStructure.shards[i])
+			results = append(results, shardResults...)
+		}
+	}
+	success = len(results) > 0
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Scan returns an array of zero or many Element numbers based on the total content of the index -- as for Scan
+//      (in key order across the shards if they are split into ranges)
+//
+func (shardedStructure *ShardedIndex) Scan() (success bool, results []int) {
+	for i := range shardedStructure.shards {
+		_, shardResults := Scan(&shardedStructure.shards[i])
+		results = append(results, shardResults...)
+	}
+	success = len(results) > 0
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Count returns a count of how many Element numbers (keys) including duplicates, are contained in all the shards
+//
+func (shardedStructure *ShardedIndex) Count() (keyCount int) {
+	for i := range shardedStructure.shards {
+		keyCount += Count(&shardedStructure.shards[i])
+	}
+	return
+}
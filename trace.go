@@ -0,0 +1,69 @@
+package index
+
+import (
+	"context"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Tracer starts a span for each call made on an index -- see SetTracer
+//        StartSpan is called before the call waits for the index (so the span includes the wait) with the context
+//        passed to ScanContext or SearchContext (context.Background() for the other calls) -- it may be called from
+//        many goroutines at once
+//
+type Tracer interface {
+	StartSpan(ctx context.Context, operation Operation) (span Span)
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Span is one call traced by a Tracer -- End is called once, after the index is unlocked, with what the call did
+//
+type Span interface {
+	End(attributes SpanAttributes)
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// SpanAttributes describes a traced call -- KeyLength is the length of the key (or search prefix, or start of the
+//                range) as passed in -- ResultCount the number of elements returned (keys for ScanKeys, SearchRegexp
+//                and SearchFuzzy -- none for the changes) -- NodesVisited how many nodes of the node array the call
+//                looked at
+//
+type SpanAttributes struct {
+	KeyLength    int
+	ResultCount  int
+	NodesVisited int
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// SetTracer has a span started by 'tracer' for every call made on the index that has an Operation -- Insert,
+//           InsertMany, Delete, Apply, Select, Search, Scan, ScanKeys, Range, Match, SearchRegexp, SearchFuzzy (and
+//           their Context forms) -- and one for each transaction, from Begin until Commit or Rollback
+//           nothing else is traced -- not Count, Statistics, Snapshot and the like -- a loop over Entries is traced
+//           as the ScanKeys calls it makes -- nil stops the tracing -- with no tracer set nothing is traced
+//
+func SetTracer(tracer Tracer, indexStructure *Index) {
+	if tracer == nil {
+		indexStructure.tracer.Store(nil)
+		return
+	}
+	indexStructure.tracer.Store(&tracer)
+}
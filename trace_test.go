@@ -0,0 +1,97 @@
+package index
+
+import (
+	"context"
+	"regexp"
+	"testing"
+)
+
+type spanRecorder struct { // a Tracer that is its own Span -- for one goroutine only
+	operations []Operation
+	attributes []SpanAttributes
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (recorder *spanRecorder) StartSpan(_ context.Context, operation Operation) (span Span) {
+	recorder.operations = append(recorder.operations, operation)
+	span = recorder
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (recorder *spanRecorder) End(attributes SpanAttributes) {
+	recorder.attributes = append(recorder.attributes, attributes)
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func (recorder *spanRecorder) nodesVisited(call func()) (nodesVisited int) {
+	// the nodes looked at by the one traced call 'call' makes
+	spanCount := len(recorder.attributes)
+	call()
+	if len(recorder.attributes) != spanCount+1 {
+		return -1
+	}
+	nodesVisited = recorder.attributes[spanCount].NodesVisited
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestFilterNodesVisited(t *testing.T) {
+	// every key of four of "abcdefgh" -- a search that can rule out branches must visit a small part of the nodes a
+	// full walk does, and one that can't must visit them all
+	indexStructure := new(Index)
+	Initialise(indexStructure)
+	keyLevel := []string{""}
+	for range 4 {
+		var nextLevel []string
+		for _, keyPrefix := range keyLevel {
+			for _, keyCharacter := range "abcdefgh" {
+				nextLevel = append(nextLevel, keyPrefix+string(keyCharacter))
+			}
+		}
+		keyLevel = nextLevel
+	}
+	for keyElement, keyString := range keyLevel {
+		Insert(keyString, keyElement, indexStructure)
+	}
+	recorder := new(spanRecorder)
+	SetTracer(recorder, indexStructure)
+	scanNodes := recorder.nodesVisited(func() { Scan(indexStructure) })
+	walkNodes := recorder.nodesVisited(func() { Match("*", indexStructure) }) // every key, nothing ruled out
+	tests := []struct {
+		name   string
+		search func()
+		pruned bool
+	}{
+		{"Match of a literal prefix", func() { Match("abc*", indexStructure) }, true},
+		{"Match from the first character", func() { Match("?bc*", indexStructure) }, true},
+		{"Match from any character", func() { Match("*bc", indexStructure) }, false},
+		{"SearchRegexp anchored", func() { SearchRegexp(regexp.MustCompile("^abc"), indexStructure) }, true},
+		{"SearchRegexp anchored any", func() { SearchRegexp(regexp.MustCompile("^.bc"), indexStructure) }, true},
+		{"SearchRegexp unanchored", func() { SearchRegexp(regexp.MustCompile("bc"), indexStructure) }, false},
+		{"SearchFuzzy one edit", func() { SearchFuzzy("abcd", 1, indexStructure) }, true},
+		{"SearchFuzzy no edits", func() { SearchFuzzy("abcd", 0, indexStructure) }, true},
+		{"SearchFuzzy every key", func() { SearchFuzzy("abcd", 4, indexStructure) }, false},
+	}
+	for _, test := range tests {
+		nodesVisited := recorder.nodesVisited(test.search)
+		if test.pruned && (nodesVisited < 0 || nodesVisited*10 > scanNodes) {
+			t.Errorf("%s visited %d nodes, want under a tenth of Scan's %d", test.name, nodesVisited, scanNodes)
+		}
+		if !test.pruned && nodesVisited != walkNodes {
+			t.Errorf("%s visited %d nodes, want the %d of a full walk", test.name, nodesVisited, walkNodes)
+		}
+	}
+}
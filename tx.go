@@ -0,0 +1,221 @@
+package index
+
+import (
+	"context"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Tx is a transaction against one index -- see Begin
+//
+type Tx struct {
+	indexStructure *Index // the index the changes are committed to
+	stagedIndex    *Index // a private copy of the index the changes are made to until they are committed
+	changeSequence uint64 // the index's changeSequence when the copy was taken
+	finished       bool
+	records        []logRecord // changes to make, log and send to subscribers when committed
+	nodesVisited   int         // by the transaction's calls -- passed on when committed
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Begin starts a transaction -- its changes are made to a private copy of the index (which shares the node array
+//       until the first change) so nobody else sees them, and the index carries on being used and changed by other
+//       calls, until Commit makes them all at once
+//       a transaction is meant for one goroutine -- its own Insert, Delete, Select and Search see its changes
+//
+func Begin(indexStructure *Index) (transaction *Tx) {
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	stagedIndex := takeSnapshot(indexStructure)
+	stagedIndex.readOnly = indexStructure.readOnly
+	transaction = &Tx{
+		indexStructure: indexStructure,
+		stagedIndex:    stagedIndex,
+		changeSequence: indexStructure.changeSequence,
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Insert places the input string into the index along with the supplied "element" number -- as part of the transaction
+//
+func (transaction *Tx) Insert(keyInput string, keyElement int) (success bool) {
+	if transaction.finished || transaction.stagedIndex.readOnly {
+		return
+	}
+	indexStructure := transaction.stagedIndex
+	keyString, keyLength := validate(keyInput, indexStructure.collation)
+	if keyLength == 0 { // nothing to look for
+		return
+	}
+	success, nodesVisited := insertKey(keyString, keyElement, indexStructure.indexRootPointer, 0, indexStructure)
+	transaction.nodesVisited += nodesVisited
+	if success {
+		transaction.records = append(transaction.records, logRecord{insertOperation, keyString, keyElement})
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Delete removes a key and its associated "element" number from the index -- as part of the transaction
+//
+func (transaction *Tx) Delete(keyInput string, keyElement int) (success bool) {
+	if transaction.finished || transaction.stagedIndex.readOnly {
+		return
+	}
+	indexStructure := transaction.stagedIndex
+	keyString, keyLength := validate(keyInput, indexStructure.collation)
+	if keyLength == 0 { // nothing to look for
+		return
+	}
+	success, nodesVisited := deleteKey(keyString, keyElement, indexStructure)
+	transaction.nodesVisited += nodesVisited
+	if success {
+		transaction.records = append(transaction.records, logRecord{deleteOperation, keyString, keyElement})
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Select returns an array of zero or many Element numbers for the input key -- as for Select, but seeing the changes
+//        made through the transaction so far
+//
+func (transaction *Tx) Select(keyInput string) (success bool, results []int) {
+	if transaction.finished {
+		return
+	}
+	indexStructure := transaction.stagedIndex
+	keyString, keyLength := validate(keyInput, indexStructure.collation)
+	if keyLength == 0 { // nothing to look for
+		return
+	}
+	keyPointer, nodesVisited := findKey(keyString, indexStructure)
+	transaction.nodesVisited += nodesVisited
+	if keyPointer == nullIndexPointer { // key doesn't match
+		return
+	}
+	results, nodesVisited = collectElements(keyPointer, indexStructure)
+	transaction.nodesVisited += nodesVisited
+	success = len(results) > 0
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Search returns an array of zero or many Element numbers for every key starting with the input -- as for Search,
+//        but seeing the changes made through the transaction so far
+//
+func (transaction *Tx) Search(keyInput string) (success bool, results []int) {
+	if transaction.finished {
+		return
+	}
+	indexStructure := transaction.stagedIndex
+	keyString, keyLength := validate(keyInput, indexStructure.collation)
+	if keyLength == 0 { // nothing to look for
+		return
+	}
+	results, nodesVisited := searchKey(keyString, indexStructure)
+	transaction.nodesVisited += nodesVisited
+	success = len(results) > 0
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Commit makes the changes made through the transaction to the index -- all at once so nobody sees only some of them
+//        if nothing else has changed the index since Begin the transaction's copy simply takes its place -- otherwise
+//        the changes are made again on top of the others (and any that no longer apply are left out)
+//        'success' is false if the transaction has already been committed or rolled back -- or if the changes
+//        couldn't be written to the index's write-ahead log (they are then thrown away)
+//
+func (transaction *Tx) Commit() (success bool) {
+	if transaction.finished {
+		return
+	}
+	indexStructure, stagedIndex, records := transaction.indexStructure, transaction.stagedIndex, transaction.records
+	transaction.finished = true // either way
+	transaction.stagedIndex = nil
+	transaction.records = nil
+	timer := lockIndex(context.Background(), OperationTransaction, 0, indexStructure)
+	defer func() { unlockIndex(timer, success, 0, indexStructure) }()
+	//
+	timer.nodesVisited = transaction.nodesVisited
+	if len(records) > 0 && !logChanges(records, indexStructure) {
+		return
+	}
+	success = true
+	if indexStructure.changeSequence == transaction.changeSequence &&
+		indexStructure.postingLists == stagedIndex.postingLists { // nothing else has changed -- publish the copy
+		indexStructure.indexRootPointer = stagedIndex.indexRootPointer
+		indexStructure.node = stagedIndex.node
+		indexStructure.deletedRootPointer = stagedIndex.deletedRootPointer
+		indexStructure.keyCount = stagedIndex.keyCount
+		indexStructure.postingList = stagedIndex.postingList
+		indexStructure.postingOwned = stagedIndex.postingOwned
+		indexStructure.deletedPostings = stagedIndex.deletedPostings
+		indexStructure.nodeShared = stagedIndex.nodeShared
+		for _, record := range records {
+			publishChange(record.operation, record.keyString, record.keyElement, indexStructure)
+		}
+		return
+	}
+	for _, record := range records { // make them again
+		applied, nodesVisited := false, 0
+		if record.operation == insertOperation {
+			applied, nodesVisited = insertKey(record.keyString, record.keyElement, indexStructure.indexRootPointer, 0,
+				indexStructure)
+		} else {
+			applied, nodesVisited = deleteKey(record.keyString, record.keyElement, indexStructure)
+		}
+		timer.nodesVisited += nodesVisited
+		if applied {
+			publishChange(record.operation, record.keyString, record.keyElement, indexStructure)
+		}
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Rollback throws away the changes made through the transaction -- the index itself was never changed by them
+//          'success' is false if the transaction has already been committed or rolled back
+//
+func (transaction *Tx) Rollback() (success bool) {
+	if transaction.finished {
+		return
+	}
+	transaction.finished = true
+	transaction.stagedIndex = nil
+	transaction.records = nil
+	success = true
+	return
+}
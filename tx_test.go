@@ -0,0 +1,205 @@
+package index
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type indexState struct {
+	indexRootPointer   int
+	node               []indexNode
+	deletedRootPointer int
+	keyCount           int
+	postingList        []postingBlocks
+	deletedPostings    []int
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func stateOf(indexStructure *Index) (state indexState) {
+	// a deep copy of everything a change can alter
+	state = indexState{
+		indexRootPointer:   indexStructure.indexRootPointer,
+		node:               slices.Clone(indexStructure.node),
+		deletedRootPointer: indexStructure.deletedRootPointer,
+		keyCount:           indexStructure.keyCount,
+		deletedPostings:    slices.Clone(indexStructure.deletedPostings),
+	}
+	for _, blocks := range indexStructure.postingList {
+		state.postingList = append(state.postingList, postingBlocksOf(appendPostingElements(nil, blocks)))
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestTxRollback(t *testing.T) {
+	// the index holds duplicates and has nodes (and posting lists) waiting on the 'deleted' lists -- a rolled back
+	// transaction that changes all of them must leave every one exactly as it was
+	for _, layout := range duplicateLayouts {
+		t.Run(layout.name, func(t *testing.T) {
+			indexStructure := newDuplicatesIndex(t, layout.postingLists, "dup", 3)
+			InsertMany("gone", []int{1, 2}, indexStructure)
+			Insert("apple", 4, indexStructure)
+			Insert("apply", 5, indexStructure)
+			Delete("gone", 1, indexStructure)
+			Delete("gone", 2, indexStructure)
+			before := stateOf(indexStructure)
+			transaction := Begin(indexStructure)
+			for keyElement := range 600 { // enough to split a posting list into blocks
+				transaction.Insert("dup", keyElement)
+			}
+			transaction.Insert("gone", 7)
+			transaction.Insert("gone", 8)
+			transaction.Delete("apple", 4)
+			transaction.Insert("banana", 9)
+			if success, results := transaction.Select("dup"); !success || len(results) != 600 {
+				t.Fatalf("the transaction's Select returned %v and %d elements", success, len(results))
+			}
+			if !transaction.Rollback() {
+				t.Fatal("Rollback failed")
+			}
+			if after := stateOf(indexStructure); !reflect.DeepEqual(after, before) {
+				t.Errorf("after Rollback the index holds %+v, want %+v", after, before)
+			}
+			if err := Verify(indexStructure); err != nil {
+				t.Error(err)
+			}
+			if transaction.Insert("cherry", 10) || transaction.Commit() || transaction.Rollback() {
+				t.Error("a rolled back transaction can still be used")
+			}
+			// the index's own changes must not alter what was put back
+			Insert("dup", 1000, indexStructure)
+			if results := selectSorted("dup", indexStructure); !slices.Equal(results, []int{0, 1, 2, 1000}) {
+				t.Errorf("after Rollback Select returned %v", results)
+			}
+		})
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestTxIsolation(t *testing.T) {
+	// calls on the index (from the same goroutine) carry on while the transaction is open and never see its changes
+	// until it is committed
+	for _, layout := range duplicateLayouts {
+		t.Run(layout.name, func(t *testing.T) {
+			indexStructure := newDuplicatesIndex(t, layout.postingLists, "dup", 2)
+			snapshot := Snapshot(indexStructure)
+			eventChannel := Subscribe(indexStructure)
+			transaction := Begin(indexStructure)
+			transaction.Insert("dup", 7)
+			transaction.Insert("new", 8)
+			transaction.Delete("dup", 0)
+			if _, results := transaction.Search(""); len(results) != 0 {
+				t.Errorf("the transaction's Search of nothing returned %v", results)
+			}
+			if _, results := transaction.Search("n"); !slices.Equal(results, []int{8}) {
+				t.Errorf("the transaction's Search returned %v", results)
+			}
+			if results := selectSorted("dup", indexStructure); !slices.Equal(results, []int{0, 1}) {
+				t.Errorf("before Commit Select returned %v", results)
+			}
+			if success, results := Search("n", indexStructure); success || Count(indexStructure) != 2 {
+				t.Errorf("before Commit Search returned %v %v and Count %d", success, results, Count(indexStructure))
+			}
+			if events, _ := drainEvents(eventChannel); len(events) != 0 {
+				t.Errorf("before Commit subscribers were sent %v", events)
+			}
+			if !transaction.Commit() {
+				t.Fatal("Commit failed")
+			}
+			if results := selectSorted("dup", indexStructure); !slices.Equal(results, []int{1, 7}) {
+				t.Errorf("after Commit Select returned %v", results)
+			}
+			if _, results := Select("new", indexStructure); !slices.Equal(results, []int{8}) {
+				t.Errorf("after Commit Select returned %v", results)
+			}
+			if events, _ := drainEvents(eventChannel); len(events) != 3 {
+				t.Errorf("after Commit subscribers were sent %v", events)
+			}
+			if results := selectSorted("dup", snapshot); !slices.Equal(results, []int{0, 1}) {
+				t.Errorf("the snapshot taken before Begin now holds %v", results)
+			}
+			if err := Verify(indexStructure); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestTxCommitAfterOtherChanges(t *testing.T) {
+	// the index is changed after Begin -- Commit must keep those changes and make the transaction's on top of them
+	for _, layout := range duplicateLayouts {
+		t.Run(layout.name, func(t *testing.T) {
+			indexStructure := newDuplicatesIndex(t, layout.postingLists, "dup", 3)
+			transaction := Begin(indexStructure)
+			transaction.Insert("dup", 7)
+			transaction.Delete("dup", 0)
+			transaction.Delete("dup", 1)
+			transaction.Insert("new", 8)
+			Insert("other", 9, indexStructure)
+			Delete("dup", 1, indexStructure) // so the transaction's Delete no longer applies
+			if !transaction.Commit() {
+				t.Fatal("Commit failed")
+			}
+			model := keyModel{"dup": {2, 7}, "new": {8}, "other": {9}}
+			checkModel(t, "after Commit", indexStructure, model)
+		})
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestTxConcurrentReaders(t *testing.T) {
+	// readers on other goroutines keep looking for the transaction's keys while it makes them -- none may be seen
+	// before Commit is called
+	indexStructure := newDuplicatesIndex(t, true, "dup", 2)
+	var committing atomic.Bool
+	var readers sync.WaitGroup
+	for range 4 {
+		readers.Go(func() {
+			for {
+				committed := committing.Load()
+				if success, results := Search("new", indexStructure); success && !committed {
+					t.Errorf("a reader saw %v before Commit", results)
+					return
+				}
+				if committed {
+					return
+				}
+			}
+		})
+	}
+	transaction := Begin(indexStructure)
+	for keyElement := range 2000 {
+		transaction.Insert("new"+string(rune('a'+keyElement%26)), keyElement)
+		Insert("dup", keyElement+10, indexStructure) // the index itself carries on changing
+	}
+	committing.Store(true)
+	if !transaction.Commit() {
+		t.Fatal("Commit failed")
+	}
+	readers.Wait()
+	if keyCount := Count(indexStructure); keyCount != 4002 {
+		t.Errorf("after Commit Count returned %d", keyCount)
+	}
+	if err := Verify(indexStructure); err != nil {
+		t.Error(err)
+	}
+}
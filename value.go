@@ -0,0 +1,203 @@
+package index
+
+import (
+	"sync"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// ValueIndex contains an index whose keys refer to values of any comparable type (UUIDs, string IDs, pointers ...)
+//            rather than to positions in an array -- each distinct value is given an element number in an Index
+//
+type ValueIndex[V comparable] struct {
+	index           Index
+	valueMutex      sync.Mutex
+	valueNumber     map[V]int // the element number given to each value
+	value           []V       // the value for each element number
+	valueUsage      []int     // how many keys refer to each element number
+	deletedElements []int     // element numbers that can be given to new values
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func numberValue[V comparable](keyValue V, valueStructure *ValueIndex[V]) (keyElement int) {
+	// finds the element number for a value -- giving it a new (or re-used) one if it hasn't got one
+	keyElement, found := valueStructure.valueNumber[keyValue]
+	if found {
+		return
+	}
+	if len(valueStructure.deletedElements) > 0 { // use up one of the 'deleted' element numbers
+		keyElement = valueStructure.deletedElements[len(valueStructure.deletedElements)-1]
+		valueStructure.deletedElements = valueStructure.deletedElements[:len(valueStructure.deletedElements)-1]
+		valueStructure.value[keyElement] = keyValue
+	} else {
+		keyElement = len(valueStructure.value)
+		valueStructure.value = append(valueStructure.value, keyValue)
+		valueStructure.valueUsage = append(valueStructure.valueUsage, 0)
+	}
+	valueStructure.valueNumber[keyValue] = keyElement
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func releaseValue[V comparable](keyElement int, valueStructure *ValueIndex[V]) {
+	// gives up an element number once no key refers to it
+	if valueStructure.valueUsage[keyElement] > 0 {
+		return
+	}
+	var zeroValue V
+	delete(valueStructure.valueNumber, valueStructure.value[keyElement])
+	valueStructure.value[keyElement] = zeroValue // don't hold on to anything the value points at
+	valueStructure.deletedElements = append(valueStructure.deletedElements, keyElement)
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func valuesOf[V comparable](elements []int, valueStructure *ValueIndex[V]) (results []V) {
+	// converts element numbers back into their values
+	if len(elements) == 0 {
+		return
+	}
+	results = make([]V, len(elements))
+	for i, keyElement := range elements {
+		results[i] = valueStructure.value[keyElement]
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// InitialiseValues sets up a value index structure -- must be executed before anything else is performed
+//
+func InitialiseValues[V comparable](valueStructure *ValueIndex[V]) {
+	valueStructure.valueMutex.Lock()
+	defer valueStructure.valueMutex.Unlock()
+	//
+	Initialise(&valueStructure.index)
+	valueStructure.valueNumber = make(map[V]int)
+	valueStructure.value = nil
+	valueStructure.valueUsage = nil
+	valueStructure.deletedElements = nil
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// InsertValue places the input string into the specified value index structure along with the supplied value
+//
+func InsertValue[V comparable](keyInput string, keyValue V, valueStructure *ValueIndex[V]) (success bool) {
+	valueStructure.valueMutex.Lock()
+	defer valueStructure.valueMutex.Unlock()
+	//
+	keyElement := numberValue(keyValue, valueStructure)
+	success = Insert(keyInput, keyElement, &valueStructure.index)
+	if success {
+		valueStructure.valueUsage[keyElement]++
+	} else { // key and value already there (or no key) -- a brand new number isn't needed
+		releaseValue(keyElement, valueStructure)
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// DeleteValue removes a key and its associated value from the supplied value index
+//
+func DeleteValue[V comparable](keyInput string, keyValue V, valueStructure *ValueIndex[V]) (success bool) {
+	valueStructure.valueMutex.Lock()
+	defer valueStructure.valueMutex.Unlock()
+	//
+	keyElement, found := valueStructure.valueNumber[keyValue]
+	if !found { // no key refers to the value
+		return
+	}
+	success = Delete(keyInput, keyElement, &valueStructure.index)
+	if success {
+		valueStructure.valueUsage[keyElement]--
+		releaseValue(keyElement, valueStructure)
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// SelectValues returns an array of zero or many values -- as Select but for a value index
+//
+func SelectValues[V comparable](keyInput string, valueStructure *ValueIndex[V]) (success bool, results []V) {
+	valueStructure.valueMutex.Lock()
+	defer valueStructure.valueMutex.Unlock()
+	//
+	success, elements := Select(keyInput, &valueStructure.index)
+	results = valuesOf(elements, valueStructure)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// SearchValues returns an array of zero or many values -- as Search but for a value index
+//
+func SearchValues[V comparable](keyInput string, valueStructure *ValueIndex[V]) (success bool, results []V) {
+	valueStructure.valueMutex.Lock()
+	defer valueStructure.valueMutex.Unlock()
+	//
+	success, elements := Search(keyInput, &valueStructure.index)
+	results = valuesOf(elements, valueStructure)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// ScanValues returns an array of zero or many values -- as Scan but for a value index
+//
+func ScanValues[V comparable](valueStructure *ValueIndex[V]) (success bool, results []V) {
+	valueStructure.valueMutex.Lock()
+	defer valueStructure.valueMutex.Unlock()
+	//
+	success, elements := Scan(&valueStructure.index)
+	results = valuesOf(elements, valueStructure)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// CountValues returns a count of how many values (keys) including duplicates, are contained in the value index
+//
+func CountValues[V comparable](valueStructure *ValueIndex[V]) (keyCount int) {
+	keyCount = Count(&valueStructure.index)
+	return
+}
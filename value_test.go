@@ -0,0 +1,70 @@
+package index
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestValueIndex(t *testing.T) {
+	// each step changes the index then checks what every key returns and how many element numbers have been given
+	// out -- a value's number is given up when no key refers to it (or its insert fails) and is then re-used for the
+	// next new value rather than a new one being given out
+	var valueStructure ValueIndex[string]
+	InitialiseValues(&valueStructure)
+	steps := []struct {
+		name         string
+		change       func() bool
+		success      bool
+		selected     map[string][]string
+		elementCount int // element numbers given out -- in use or waiting to be re-used
+	}{
+		{"insert", func() bool { return InsertValue("apple", "id-1", &valueStructure) }, true,
+			map[string][]string{"apple": {"id-1"}}, 1},
+		{"insert another value", func() bool { return InsertValue("apple", "id-2", &valueStructure) }, true,
+			map[string][]string{"apple": {"id-1", "id-2"}}, 2},
+		{"same value on another key", func() bool { return InsertValue("banana", "id-1", &valueStructure) }, true,
+			map[string][]string{"apple": {"id-1", "id-2"}, "banana": {"id-1"}}, 2},
+		{"insert again", func() bool { return InsertValue("apple", "id-2", &valueStructure) }, false,
+			map[string][]string{"apple": {"id-1", "id-2"}, "banana": {"id-1"}}, 2},
+		{"failed insert of a new value", func() bool { return InsertValue(" ", "id-9", &valueStructure) }, false,
+			map[string][]string{"apple": {"id-1", "id-2"}, "banana": {"id-1"}}, 3},
+		{"delete a value still used", func() bool { return DeleteValue("apple", "id-1", &valueStructure) }, true,
+			map[string][]string{"apple": {"id-2"}, "banana": {"id-1"}}, 3},
+		{"delete a value never inserted", func() bool { return DeleteValue("apple", "id-7", &valueStructure) }, false,
+			map[string][]string{"apple": {"id-2"}, "banana": {"id-1"}}, 3},
+		{"delete the last use", func() bool { return DeleteValue("banana", "id-1", &valueStructure) }, true,
+			map[string][]string{"apple": {"id-2"}, "banana": nil}, 3},
+		{"new value re-uses a number", func() bool { return InsertValue("cherry", "id-3", &valueStructure) }, true,
+			map[string][]string{"apple": {"id-2"}, "banana": nil, "cherry": {"id-3"}}, 3},
+		{"deleted value re-uses a number", func() bool { return InsertValue("banana", "id-1", &valueStructure) },
+			true, map[string][]string{"apple": {"id-2"}, "banana": {"id-1"}, "cherry": {"id-3"}}, 3},
+		{"delete and insert on one key", func() bool {
+			return DeleteValue("apple", "id-2", &valueStructure) && InsertValue("apple", "id-4", &valueStructure)
+		}, true, map[string][]string{"apple": {"id-4"}, "banana": {"id-1"}, "cherry": {"id-3"}}, 3},
+	}
+	for _, step := range steps {
+		if success := step.change(); success != step.success {
+			t.Fatalf("%s: got %v, want %v", step.name, success, step.success)
+		}
+		for keyString, want := range step.selected {
+			success, results := SelectValues(keyString, &valueStructure)
+			slices.Sort(results)
+			if success != (len(want) > 0) || !slices.Equal(results, want) {
+				t.Errorf("%s: %q selected %v %v, want %v", step.name, keyString, success, results, want)
+			}
+		}
+		if elementCount := len(valueStructure.value); elementCount != step.elementCount {
+			t.Errorf("%s: %d element numbers given out, want %d", step.name, elementCount, step.elementCount)
+		}
+	}
+	if success, results := SearchValues("ba", &valueStructure); !success || !slices.Equal(results, []string{"id-1"}) {
+		t.Errorf("SearchValues returned %v %v", success, results)
+	}
+	if success, results := ScanValues(&valueStructure); !success || !slices.Equal(results, []string{"id-4", "id-1",
+		"id-3"}) {
+		t.Errorf("ScanValues returned %v %v", success, results)
+	}
+	if CountValues(&valueStructure) != 3 {
+		t.Errorf("CountValues returned %d", CountValues(&valueStructure))
+	}
+}
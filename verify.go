@@ -0,0 +1,244 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//  CONSTANTS and PSEUDO-CONSTANTS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// ErrInvalid is returned by Verify for an index whose node array isn't a sound index -- the error says where
+var ErrInvalid = errors.New("index: index structure is invalid")
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type indexVerifier struct {
+	indexStructure *Index
+	nodeReached    []bool // every node must be reached exactly once -- from the root or on the 'deleted' list
+	postingUsed    []bool
+	keyCount       int // elements found -- must match the index's count
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func invalidNode(indexPointer int, problem string) error {
+	return fmt.Errorf("%w: node %d %s", ErrInvalid, indexPointer, problem)
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func reachNode(indexPointer int, verifier *indexVerifier) (err error) {
+	// checks a pointer is to a node in the array that hasn't already been reached
+	if indexPointer < 0 || indexPointer >= len(verifier.indexStructure.node) {
+		err = fmt.Errorf("%w: pointer %d is outside the node array", ErrInvalid, indexPointer)
+		return
+	}
+	if verifier.nodeReached[indexPointer] {
+		err = invalidNode(indexPointer, "is reached more than once")
+		return
+	}
+	verifier.nodeReached[indexPointer] = true
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func verifyBranch(indexPointer int, keyPrefix []byte, lowCharacter, highCharacter byte, threadPointer int,
+	duplicatesBranch bool, verifier *indexVerifier) (err error) {
+	// checks a 'branch' as 'walkKeys' would visit it -- every character in its range, every 'decisionNode' splitting
+	// its range, every branch ending in a 'terminal' node threaded to 'threadPointer' -- in a 'duplicates' branch
+	// each key must be the decimal of its element
+	indexStructure := verifier.indexStructure
+	for {
+		if indexPointer == nullIndexPointer {
+			err = fmt.Errorf("%w: branch %q ends without a terminal node", ErrInvalid, keyPrefix)
+			return
+		}
+		if err = reachNode(indexPointer, verifier); err != nil {
+			return
+		}
+		thisNode := indexStructure.node[indexPointer]
+		if thisNode.indexType == decisionNode {
+			if thisNode.keyCharacter < lowCharacter || thisNode.keyCharacter >= highCharacter {
+				err = invalidNode(indexPointer, "decides on a character outside its branch's range")
+				return
+			}
+			err = verifyBranch(thisNode.leftPointer, keyPrefix, lowCharacter, thisNode.keyCharacter, indexPointer,
+				duplicatesBranch, verifier)
+			if err != nil {
+				return
+			}
+			lowCharacter = thisNode.keyCharacter + 1
+			indexPointer = thisNode.rightPointer
+			continue
+		}
+		if thisNode.keyCharacter < lowCharacter || thisNode.keyCharacter > highCharacter {
+			err = invalidNode(indexPointer, "has a character outside its branch's range -- keys are out of order")
+			return
+		}
+		keyPrefix = append(keyPrefix, thisNode.keyCharacter)
+		if len(keyPrefix) > maxKeyLength {
+			err = invalidNode(indexPointer, "makes a key longer than "+strconv.Itoa(maxKeyLength))
+			return
+		}
+		switch thisNode.indexType {
+		case characterNode:
+		case indexKeyNode, indexTerminalNode:
+			if duplicatesBranch && string(keyPrefix) != strconv.Itoa(thisNode.leftPointer) {
+				err = invalidNode(indexPointer, "holds an element that doesn't match its 'duplicates' key")
+				return
+			}
+			verifier.keyCount++
+		case duplicateKeyNode, duplicateTerminalNode:
+			if duplicatesBranch {
+				err = invalidNode(indexPointer, "is a 'duplicate' node inside a 'duplicates' branch")
+				return
+			}
+			if err = verifyDuplicates(indexPointer, verifier); err != nil {
+				return
+			}
+		default:
+			err = invalidNode(indexPointer, "has unknown type "+strconv.QuoteRune(rune(thisNode.indexType)))
+			return
+		}
+		if thisNode.indexType == indexTerminalNode || thisNode.indexType == duplicateTerminalNode {
+			if thisNode.rightPointer != threadPointer {
+				err = invalidNode(indexPointer, "is threaded to "+strconv.Itoa(thisNode.rightPointer)+" not "+
+					strconv.Itoa(threadPointer))
+			}
+			return
+		}
+		lowCharacter, highCharacter = 0, 255
+		indexPointer = thisNode.rightPointer
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func verifyDuplicates(keyPointer int, verifier *indexVerifier) (err error) {
+	// checks the elements of a 'duplicate' node -- a posting list or a 'duplicates' branch threaded back to the node
+	// -- either way there must be at least two
+	indexStructure := verifier.indexStructure
+	if !indexStructure.postingLists {
+		keyCount := verifier.keyCount
+		err = verifyBranch(indexStructure.node[keyPointer].leftPointer, nil, 0, 255, keyPointer, true, verifier)
+		if err == nil && verifier.keyCount-keyCount < 2 {
+			err = invalidNode(keyPointer, "is a 'duplicate' node with fewer than two elements")
+		}
+		return
+	}
+	postingPointer := indexStructure.node[keyPointer].leftPointer
+	if postingPointer < 0 || postingPointer >= len(indexStructure.postingList) || verifier.postingUsed[postingPointer] {
+		err = invalidNode(keyPointer, "has a posting list that is missing or shared")
+		return
+	}
+	verifier.postingUsed[postingPointer] = true
+	for _, block := range indexStructure.postingList[postingPointer] {
+		if len(block) == 0 || len(block) > postingBlockLength {
+			err = invalidNode(keyPointer, "has a posting list block that is empty or too long")
+			return
+		}
+	}
+	keyElements := appendPostingElements(nil, indexStructure.postingList[postingPointer])
+	if len(keyElements) < 2 {
+		err = invalidNode(keyPointer, "is a 'duplicate' node with fewer than two elements")
+		return
+	}
+	for i := 1; i < len(keyElements); i++ {
+		if keyElements[i] <= keyElements[i-1] {
+			err = invalidNode(keyPointer, "has a posting list that is out of order or repeats an element")
+			return
+		}
+	}
+	verifier.keyCount += len(keyElements)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Verify checks the whole node array of an index -- every node in use is reached once from the root and is in key
+//        order, every 'branch' is threaded back correctly, every 'duplicate' node holds two or more elements and
+//        every other node is on the 'deleted' list -- and that the count of keys is right
+//        'err' wraps ErrInvalid and names the first problem found (nil if there is none)
+//
+func Verify(indexStructure *Index) (err error) {
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	verifier := indexVerifier{
+		indexStructure: indexStructure,
+		nodeReached:    make([]bool, len(indexStructure.node)),
+		postingUsed:    make([]bool, len(indexStructure.postingList)),
+	}
+	if indexStructure.indexRootPointer != nullIndexPointer {
+		err = verifyBranch(indexStructure.indexRootPointer, nil, 0, 255, nullIndexPointer, false, &verifier)
+		if err != nil {
+			return
+		}
+	}
+	for x := indexStructure.deletedRootPointer; x != nullIndexPointer; x = indexStructure.node[x].rightPointer {
+		if err = reachNode(x, &verifier); err != nil { // in use as well, or the list loops
+			return
+		}
+	}
+	for indexPointer, reached := range verifier.nodeReached {
+		if !reached {
+			err = invalidNode(indexPointer, "is neither in the index nor on the 'deleted' list")
+			return
+		}
+	}
+	if verifier.keyCount != indexStructure.keyCount {
+		err = fmt.Errorf("%w: %d elements found but the count is %d", ErrInvalid, verifier.keyCount,
+			indexStructure.keyCount)
+		return
+	}
+	deletedPostings := append([]int(nil), indexStructure.deletedPostings...)
+	sort.Ints(deletedPostings)
+	for i, postingPointer := range deletedPostings {
+		if postingPointer < 0 || postingPointer >= len(verifier.postingUsed) || verifier.postingUsed[postingPointer] ||
+			(i > 0 && postingPointer == deletedPostings[i-1]) {
+			err = fmt.Errorf("%w: deleted posting list %d is in use or listed twice", ErrInvalid, postingPointer)
+			return
+		}
+		verifier.postingUsed[postingPointer] = true
+	}
+	for postingPointer, used := range verifier.postingUsed {
+		if !used {
+			err = fmt.Errorf("%w: posting list %d is neither in use nor deleted", ErrInvalid, postingPointer)
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,147 @@
+package index
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func keyNode(keyString string, indexStructure *Index) (keyPointer int) {
+	keyPointer, _ = findKey(keyString, indexStructure)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func duplicatesNode(keyElement int, indexStructure *Index) (indexPointer int) {
+	// finds the node of a 'duplicates' branch holding an element -- the other keys hold different elements
+	for indexPointer = range indexStructure.node {
+		thisNode := indexStructure.node[indexPointer]
+		if (thisNode.indexType == indexKeyNode || thisNode.indexType == indexTerminalNode) &&
+			thisNode.leftPointer == keyElement {
+			return
+		}
+	}
+	indexPointer = nullIndexPointer
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestVerifyCorruption(t *testing.T) {
+	// each kind of problem Verify looks for is made in a sound index -- "dup" holds elements 0 and 1, "ab" is a
+	// key node followed by "abc", "b" comes after a 'decision' on 'b', "x" was deleted leaving nodes on the 'deleted'
+	// list -- and must be reported
+	tests := []struct {
+		name             string
+		duplicatesBranch bool // which layouts the problem can be made in
+		postingLists     bool
+		corrupt          func(indexStructure *Index)
+		problem          string
+	}{
+		{"pointer outside the array", true, true, func(indexStructure *Index) {
+			indexStructure.node[keyNode("ab", indexStructure)].rightPointer = len(indexStructure.node)
+		}, "is outside the node array"},
+		{"branch not ended", true, true, func(indexStructure *Index) {
+			characterPointer := indexStructure.node[indexStructure.indexRootPointer].leftPointer
+			indexStructure.node[characterPointer].rightPointer = nullIndexPointer
+		}, "ends without a terminal node"},
+		{"node reached twice", true, true, func(indexStructure *Index) {
+			indexStructure.node[keyNode("ab", indexStructure)].rightPointer = indexStructure.indexRootPointer
+		}, "is reached more than once"},
+		{"'deleted' list loops", true, true, func(indexStructure *Index) {
+			indexStructure.node[indexStructure.deletedRootPointer].rightPointer = indexStructure.deletedRootPointer
+		}, "is reached more than once"},
+		{"node in use on the 'deleted' list", true, true, func(indexStructure *Index) {
+			indexStructure.deletedRootPointer = keyNode("b", indexStructure)
+		}, "is reached more than once"},
+		{"node lost", true, true, func(indexStructure *Index) {
+			indexStructure.deletedRootPointer = nullIndexPointer
+		}, "is neither in the index nor on the 'deleted' list"},
+		{"decision out of range", true, true, func(indexStructure *Index) {
+			indexStructure.node[indexStructure.node[indexStructure.indexRootPointer].rightPointer].keyCharacter = 'a'
+		}, "decides on a character outside its branch's range"},
+		{"keys out of order", true, true, func(indexStructure *Index) {
+			indexStructure.node[keyNode("b", indexStructure)].keyCharacter = 'a'
+		}, "keys are out of order"},
+		{"key too long", true, true, func(indexStructure *Index) {
+			// "b" carries on with enough characters to make it one longer than a key can be
+			keyPointer := keyNode("b", indexStructure)
+			threadPointer := indexStructure.node[keyPointer].rightPointer
+			indexStructure.node[keyPointer].indexType = characterNode
+			indexStructure.node[keyPointer].rightPointer = len(indexStructure.node)
+			for range maxKeyLength {
+				indexStructure.node = append(indexStructure.node,
+					indexNode{indexType: characterNode, keyCharacter: 'z', rightPointer: len(indexStructure.node) + 1})
+			}
+			lastNode := &indexStructure.node[len(indexStructure.node)-1]
+			lastNode.indexType, lastNode.rightPointer = indexTerminalNode, threadPointer
+		}, "makes a key longer than"},
+		{"unknown type", true, true, func(indexStructure *Index) {
+			indexStructure.node[indexStructure.node[indexStructure.indexRootPointer].leftPointer].indexType = 'Q'
+		}, "has unknown type"},
+		{"threaded wrongly", true, true, func(indexStructure *Index) {
+			indexStructure.node[keyNode("abc", indexStructure)].rightPointer = nullIndexPointer
+		}, "is threaded to"},
+		{"count wrong", true, true, func(indexStructure *Index) {
+			indexStructure.keyCount++
+		}, "elements found but the count is"},
+		{"element not its 'duplicates' key", true, false, func(indexStructure *Index) {
+			indexStructure.node[duplicatesNode(0, indexStructure)].leftPointer = 7
+		}, "holds an element that doesn't match its 'duplicates' key"},
+		{"'duplicate' node in a 'duplicates' branch", true, false, func(indexStructure *Index) {
+			indexStructure.node[duplicatesNode(1, indexStructure)].indexType = duplicateTerminalNode
+		}, "is a 'duplicate' node inside a 'duplicates' branch"},
+		{"'duplicates' branch of one element", true, false, func(indexStructure *Index) {
+			indexStructure.node[keyNode("dup", indexStructure)].leftPointer = duplicatesNode(1, indexStructure)
+		}, "is a 'duplicate' node with fewer than two elements"},
+		{"posting list of one element", false, true, func(indexStructure *Index) {
+			indexStructure.postingList[0] = postingBlocks{{0}}
+		}, "is a 'duplicate' node with fewer than two elements"},
+		{"posting list missing", false, true, func(indexStructure *Index) {
+			indexStructure.node[keyNode("dup", indexStructure)].leftPointer = len(indexStructure.postingList)
+		}, "has a posting list that is missing or shared"},
+		{"posting list out of order", false, true, func(indexStructure *Index) {
+			indexStructure.postingList[0] = postingBlocks{{1, 0}}
+		}, "has a posting list that is out of order or repeats an element"},
+		{"posting list blocks out of order", false, true, func(indexStructure *Index) {
+			indexStructure.postingList[0] = postingBlocks{{1}, {0}}
+		}, "has a posting list that is out of order or repeats an element"},
+		{"posting list block empty", false, true, func(indexStructure *Index) {
+			indexStructure.postingList[0] = postingBlocks{{0}, {}, {1}}
+		}, "has a posting list block that is empty or too long"},
+		{"posting list in use and deleted", false, true, func(indexStructure *Index) {
+			indexStructure.deletedPostings = append(indexStructure.deletedPostings, 0)
+		}, "is in use or listed twice"},
+		{"posting list lost", false, true, func(indexStructure *Index) {
+			indexStructure.postingList = append(indexStructure.postingList, postingBlocks{{7, 8}})
+		}, "is neither in use nor deleted"},
+	}
+	for _, layout := range duplicateLayouts {
+		for _, test := range tests {
+			if (layout.postingLists && !test.postingLists) || (!layout.postingLists && !test.duplicatesBranch) {
+				continue
+			}
+			t.Run(layout.name+"/"+test.name, func(t *testing.T) {
+				indexStructure := newDuplicatesIndex(t, layout.postingLists, "dup", 2)
+				Insert("ab", 10, indexStructure)
+				Insert("abc", 11, indexStructure)
+				Insert("b", 12, indexStructure)
+				Insert("x", 13, indexStructure)
+				Delete("x", 13, indexStructure)
+				if err := Verify(indexStructure); err != nil {
+					t.Fatalf("before the corruption: %v", err)
+				}
+				test.corrupt(indexStructure)
+				err := Verify(indexStructure)
+				if !errors.Is(err, ErrInvalid) || !strings.Contains(err.Error(), test.problem) {
+					t.Errorf("got %v, want %q", err, test.problem)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,396 @@
+package index
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//  CONSTANTS and PSEUDO-CONSTANTS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+const (
+	// SyncAlways forces every change to disk (fsync) before it is made to the index
+	SyncAlways SyncPolicy = iota
+	// SyncInterval forces changes to disk every so often -- a crash of the machine can lose the last interval
+	SyncInterval
+	// SyncNever leaves it to the operating system -- only a crash of the program itself loses nothing
+	SyncNever
+)
+
+const (
+	checkpointFileName    = "index.checkpoint"
+	logFileName           = "index.log"
+	logRecordHeaderLength = 8                      // length and checksum of the record that follows
+	defaultSyncInterval   = 100 * time.Millisecond // used when a SyncInterval log isn't given an interval
+)
+
+var (
+	// ErrLogged is returned when an index already has a write-ahead log (or has one that would stop matching it)
+	ErrLogged = errors.New("index: index has a write-ahead log")
+	// ErrClosed is returned when a write-ahead log has been closed
+	ErrClosed = errors.New("index: write-ahead log is closed")
+)
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   DATA-STRUCTURES
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// SyncPolicy says how often a write-ahead log forces what has been written out to disk
+//
+type SyncPolicy int
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+type logRecord struct {
+	operation  byte // insertOperation or deleteOperation
+	keyString  string
+	keyElement int
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// WAL is a write-ahead log for one index -- every change is written to the log before it is made to the index so
+//     the index can be put back together after a crash from the last checkpoint and the log -- see OpenWAL
+//
+type WAL struct {
+	indexStructure *Index
+	directoryPath  string
+	logFile        *os.File
+	logSize        int64 // everything after this is a failed write that has to be cut off
+	syncPolicy     SyncPolicy
+	logBuffer      []byte
+	closed         bool
+	unsynced       atomic.Bool // written but not yet forced to disk
+	stopSyncing    chan struct{}
+	syncingDone    chan struct{}
+	failureMutex   sync.Mutex
+	logFailure     error // the last write (or sync) that failed
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   FUNCTIONS
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func recordFailure(err error, wal *WAL) {
+	wal.failureMutex.Lock()
+	wal.logFailure = err
+	wal.failureMutex.Unlock()
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func logChanges(records []logRecord, indexStructure *Index) (success bool) {
+	// writes changes to the index's log (if it has one) before they are made -- the index must be locked
+	// each record is: payload length, payload checksum (4 bytes each) then the operation, element and key
+	wal := indexStructure.writeAheadLog
+	if wal == nil {
+		success = true
+		return
+	}
+	logBuffer := wal.logBuffer[:0]
+	for _, record := range records {
+		recordStart := len(logBuffer)
+		logBuffer = append(logBuffer, make([]byte, logRecordHeaderLength)...)
+		logBuffer = append(logBuffer, record.operation)
+		logBuffer = binary.AppendVarint(logBuffer, int64(record.keyElement))
+		logBuffer = append(logBuffer, record.keyString...)
+		payload := logBuffer[recordStart+logRecordHeaderLength:]
+		binary.LittleEndian.PutUint32(logBuffer[recordStart:], uint32(len(payload)))
+		binary.LittleEndian.PutUint32(logBuffer[recordStart+4:], crc32.ChecksumIEEE(payload))
+	}
+	wal.logBuffer = logBuffer
+	_, err := wal.logFile.Write(logBuffer)
+	if err == nil && wal.syncPolicy == SyncAlways {
+		err = wal.logFile.Sync()
+	}
+	if err != nil { // cut off anything part written so the log stays readable
+		recordFailure(err, wal)
+		wal.logFile.Truncate(wal.logSize)
+		return
+	}
+	wal.logSize += int64(len(logBuffer))
+	if wal.syncPolicy == SyncInterval {
+		wal.unsynced.Store(true)
+	}
+	success = true
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func replayLog(logContent []byte, indexStructure *Index) (goodLength int) {
+	// makes the changes in the log to the index -- stopping at the first record that is incomplete or damaged
+	// (the one being written when it crashed) -- 'goodLength' is where the good records end
+	for len(logContent)-goodLength >= logRecordHeaderLength {
+		payloadLength := int(binary.LittleEndian.Uint32(logContent[goodLength:]))
+		payloadStart := goodLength + logRecordHeaderLength
+		if payloadLength < 2 || payloadLength > len(logContent)-payloadStart {
+			return
+		}
+		payload := logContent[payloadStart : payloadStart+payloadLength]
+		if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(logContent[goodLength+4:]) {
+			return
+		}
+		keyElement, numberLength := binary.Varint(payload[1:])
+		if numberLength <= 0 {
+			return
+		}
+		keyString := string(payload[1+numberLength:])
+		if len(keyString) == 0 || len(keyString) > maxKeyLength {
+			return
+		}
+		switch payload[0] {
+		case insertOperation:
+			insertKey(keyString, int(keyElement), indexStructure.indexRootPointer, 0, indexStructure)
+		case deleteOperation:
+			deleteKey(keyString, int(keyElement), indexStructure)
+		default:
+			return
+		}
+		goodLength = payloadStart + payloadLength
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func syncPeriodically(syncInterval time.Duration, wal *WAL) {
+	// forces the log to disk every interval (if anything has been written) until the log is closed
+	defer close(wal.syncingDone)
+	syncTicker := time.NewTicker(syncInterval)
+	defer syncTicker.Stop()
+	for {
+		select {
+		case <-wal.stopSyncing:
+			return
+		case <-syncTicker.C:
+			if wal.unsynced.Swap(false) {
+				if err := wal.logFile.Sync(); err != nil {
+					recordFailure(err, wal)
+				}
+			}
+		}
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func syncDirectory(directoryPath string) (err error) {
+	// makes a file being created or renamed in the directory stick
+	directory, err := os.Open(directoryPath)
+	if err != nil {
+		return
+	}
+	err = directory.Sync()
+	if closeErr := directory.Close(); err == nil {
+		err = closeErr
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+//   'USER' INTERFACE
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// OpenWAL attaches a write-ahead log kept in the directory to an empty index -- the index is first rebuilt from the
+//         last checkpoint there (if any) and then every change in the log since it is made again
+//         from then on every Insert, Delete (and InsertMany, Apply and Tx Commit) is written to the log before it is
+//         made -- a change that can't be written fails and Err says why
+//         the index should have the same collation (and posting list setting) each time the log is opened
+//
+func OpenWAL(directoryPath string, syncPolicy SyncPolicy, syncInterval time.Duration,
+	indexStructure *Index) (wal *WAL, err error) {
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	switch {
+	case indexStructure.readOnly:
+		err = ErrReadOnly
+	case indexStructure.writeAheadLog != nil:
+		err = ErrLogged
+	case indexStructure.indexRootPointer != nullIndexPointer:
+		err = ErrNotEmpty
+	}
+	if err != nil {
+		return
+	}
+	err = os.MkdirAll(directoryPath, 0o755)
+	if err != nil {
+		return
+	}
+	checkpointFile, err := os.Open(filepath.Join(directoryPath, checkpointFileName))
+	switch {
+	case err == nil:
+		var entries []sortedEntry
+		entries, err = loadIndex(checkpointFile)
+		checkpointFile.Close()
+		if err != nil {
+			return
+		}
+		buildIndex(entries, indexStructure)
+	case errors.Is(err, os.ErrNotExist): // nothing checkpointed yet
+		err = nil
+	default:
+		return
+	}
+	logFile, err := os.OpenFile(filepath.Join(directoryPath, logFileName), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return
+	}
+	logContent, err := io.ReadAll(logFile)
+	if err == nil {
+		goodLength := replayLog(logContent, indexStructure)
+		if goodLength < len(logContent) { // cut off the damaged end so new records follow on from the good ones
+			err = logFile.Truncate(int64(goodLength))
+		}
+		logContent = logContent[:goodLength]
+	}
+	if err != nil {
+		logFile.Close()
+		return
+	}
+	wal = &WAL{
+		indexStructure: indexStructure,
+		directoryPath:  directoryPath,
+		logFile:        logFile,
+		logSize:        int64(len(logContent)),
+		syncPolicy:     syncPolicy,
+	}
+	if syncPolicy == SyncInterval {
+		if syncInterval <= 0 {
+			syncInterval = defaultSyncInterval
+		}
+		wal.stopSyncing = make(chan struct{})
+		wal.syncingDone = make(chan struct{})
+		go syncPeriodically(syncInterval, wal)
+	}
+	indexStructure.writeAheadLog = wal
+	contentReplaced(indexStructure) // subscribers haven't been sent what was recovered
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Checkpoint writes the whole index to the checkpoint file and empties the log -- the index is locked while it is
+//            written -- if it fails part way through the old checkpoint and log are still there to recover from
+//
+func (wal *WAL) Checkpoint() (err error) {
+	indexStructure := wal.indexStructure
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	if wal.closed {
+		err = ErrClosed
+		return
+	}
+	checkpointPath := filepath.Join(wal.directoryPath, checkpointFileName)
+	newCheckpointFile, err := os.Create(checkpointPath + ".new")
+	if err != nil {
+		return
+	}
+	err = saveIndex(newCheckpointFile, indexStructure)
+	if err == nil {
+		err = newCheckpointFile.Sync()
+	}
+	if closeErr := newCheckpointFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(checkpointPath+".new", checkpointPath)
+	}
+	if err != nil {
+		os.Remove(checkpointPath + ".new")
+		return
+	}
+	err = syncDirectory(wal.directoryPath)
+	if err != nil { // the new checkpoint may not stick -- so keep the log
+		return
+	}
+	// replaying the old log over the new checkpoint would give the same result -- so a crash from here on is safe
+	err = wal.logFile.Truncate(0)
+	if err == nil {
+		wal.logSize = 0
+		err = wal.logFile.Sync()
+	}
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Err returns the reason the last change (or background sync) that failed to be written to the log failed
+//
+func (wal *WAL) Err() (err error) {
+	wal.failureMutex.Lock()
+	defer wal.failureMutex.Unlock()
+	//
+	err = wal.logFailure
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+// Close forces the log to disk and detaches it from the index -- the index carries on without one
+//
+func (wal *WAL) Close() (err error) {
+	indexStructure := wal.indexStructure
+	indexStructure.indexMutex.Lock()
+	defer indexStructure.indexMutex.Unlock()
+	//
+	if wal.closed {
+		err = ErrClosed
+		return
+	}
+	wal.closed = true
+	indexStructure.writeAheadLog = nil
+	if wal.stopSyncing != nil {
+		close(wal.stopSyncing)
+		<-wal.syncingDone
+	}
+	err = wal.logFile.Sync()
+	if closeErr := wal.logFile.Close(); err == nil {
+		err = closeErr
+	}
+	return
+}
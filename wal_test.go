@@ -0,0 +1,197 @@
+package index
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func openTestWAL(t *testing.T, directoryPath string) (indexStructure *Index, wal *WAL) {
+	// opens the log in the directory on a new index -- closing it (if it is still open) when the test ends
+	t.Helper()
+	indexStructure = new(Index)
+	Initialise(indexStructure)
+	wal, err := OpenWAL(directoryPath, SyncNever, 0, indexStructure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { wal.Close() })
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func allEntries(indexStructure *Index) (entries []KeyEntry) {
+	entries, _ = ScanKeys("", Count(indexStructure)+1, indexStructure)
+	return
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestWALRecovery(t *testing.T) {
+	// each change is made to a logged index and to one without a log -- the log is then opened again without being
+	// closed (as after a crash) and must give back the same keys
+	tests := []struct {
+		name    string
+		changes func(indexStructure *Index, checkpoint func())
+	}{
+		{"nothing", func(indexStructure *Index, checkpoint func()) {}},
+		{"inserts and deletes", func(indexStructure *Index, checkpoint func()) {
+			Insert("apple", 1, indexStructure)
+			Insert("apply", 2, indexStructure)
+			Insert("banana", 3, indexStructure)
+			Delete("apply", 2, indexStructure)
+			Delete("cherry", 4, indexStructure) // not there -- not logged
+		}},
+		{"duplicates", func(indexStructure *Index, checkpoint func()) {
+			InsertMany("apple", []int{5, 1, 12, 5}, indexStructure)
+			Insert("apple", 3, indexStructure)
+			Delete("apple", 12, indexStructure)
+		}},
+		{"batch", func(indexStructure *Index, checkpoint func()) {
+			var batch Batch
+			batch.Insert("apple", 1)
+			batch.Insert("", 2) // fails -- not logged
+			batch.Insert("banana", 3)
+			batch.Delete("apple", 1)
+			Apply(indexStructure, &batch)
+		}},
+		{"transactions", func(indexStructure *Index, checkpoint func()) {
+			transaction := Begin(indexStructure)
+			transaction.Insert("apple", 1)
+			transaction.Insert("banana", 2)
+			transaction.Commit()
+			transaction = Begin(indexStructure)
+			transaction.Insert("cherry", 3)
+			transaction.Delete("apple", 1)
+			transaction.Rollback()
+		}},
+		{"changes after a checkpoint", func(indexStructure *Index, checkpoint func()) {
+			Insert("apple", 1, indexStructure)
+			InsertMany("banana", []int{2, 3}, indexStructure)
+			checkpoint()
+			Delete("apple", 1, indexStructure)
+			Insert("cherry", 4, indexStructure)
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			directoryPath := t.TempDir()
+			loggedStructure, wal := openTestWAL(t, directoryPath)
+			test.changes(loggedStructure, func() {
+				if err := wal.Checkpoint(); err != nil {
+					t.Fatal(err)
+				}
+			})
+			var unloggedStructure Index
+			Initialise(&unloggedStructure)
+			test.changes(&unloggedStructure, func() {})
+			recoveredStructure, _ := openTestWAL(t, directoryPath)
+			recovered, want := allEntries(recoveredStructure), allEntries(&unloggedStructure)
+			if !reflect.DeepEqual(recovered, want) {
+				t.Errorf("recovered %v, want %v", recovered, want)
+			}
+			if Count(recoveredStructure) != Count(&unloggedStructure) {
+				t.Errorf("recovered %d keys, want %d", Count(recoveredStructure), Count(&unloggedStructure))
+			}
+		})
+	}
+}
+
+//
+/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-/////////-
+//
+
+func TestWALDamagedTail(t *testing.T) {
+	// three records are logged -- then the log is damaged as a crash (or a bad disk) would leave it -- every record
+	// before the first damaged one must be recovered and the log cut off after them so new records follow on
+	keyStrings := []string{"apple", "banana", "cherry"}
+	tests := []struct {
+		name      string
+		damage    func(logContent []byte, recordEnds []int) []byte
+		goodCount int // records before the damage
+	}{
+		{"torn header", func(logContent []byte, recordEnds []int) []byte {
+			return append(logContent, 9, 0, 0)
+		}, 3},
+		{"torn payload", func(logContent []byte, recordEnds []int) []byte {
+			return append(logContent, 20, 0, 0, 0, 1, 2, 3, 4, insertOperation, 2)
+		}, 3},
+		{"no payload", func(logContent []byte, recordEnds []int) []byte {
+			return append(logContent, make([]byte, logRecordHeaderLength)...)
+		}, 3},
+		{"last record cut short", func(logContent []byte, recordEnds []int) []byte {
+			return logContent[:recordEnds[2]-1]
+		}, 2},
+		{"checksum of the last record wrong", func(logContent []byte, recordEnds []int) []byte {
+			logContent[recordEnds[1]+4] ^= 0xff
+			return logContent
+		}, 2},
+		{"key of the middle record changed", func(logContent []byte, recordEnds []int) []byte {
+			logContent[recordEnds[1]-1] ^= 0x01
+			return logContent
+		}, 1},
+		{"length of the first record too long", func(logContent []byte, recordEnds []int) []byte {
+			binary.LittleEndian.PutUint32(logContent, uint32(len(logContent)))
+			return logContent
+		}, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			directoryPath := t.TempDir()
+			logPath := filepath.Join(directoryPath, logFileName)
+			indexStructure, wal := openTestWAL(t, directoryPath)
+			var recordEnds []int
+			for i, keyString := range keyStrings {
+				Insert(keyString, i, indexStructure)
+				logInformation, err := os.Stat(logPath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				recordEnds = append(recordEnds, int(logInformation.Size()))
+			}
+			wal.Close()
+			logContent, err := os.ReadFile(logPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = os.WriteFile(logPath, test.damage(logContent, recordEnds), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			var want []KeyEntry
+			for i, keyString := range keyStrings[:test.goodCount] {
+				want = append(want, KeyEntry{keyString, []int{i}})
+			}
+			recoveredStructure, wal := openTestWAL(t, directoryPath)
+			if recovered := allEntries(recoveredStructure); !reflect.DeepEqual(recovered, want) {
+				t.Fatalf("recovered %v, want %v", recovered, want)
+			}
+			goodLength := 0
+			if test.goodCount > 0 {
+				goodLength = recordEnds[test.goodCount-1]
+			}
+			logInformation, err := os.Stat(logPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if logInformation.Size() != int64(goodLength) {
+				t.Fatalf("log is %d bytes, want it cut off after the good records at %d", logInformation.Size(),
+					goodLength)
+			}
+			// a record written now follows on from the good ones
+			Insert("date", 9, recoveredStructure)
+			wal.Close()
+			want = append(want, KeyEntry{"date", []int{9}})
+			reopenedStructure, _ := openTestWAL(t, directoryPath)
+			if recovered := allEntries(reopenedStructure); !reflect.DeepEqual(recovered, want) {
+				t.Errorf("after a new record recovered %v, want %v", recovered, want)
+			}
+		})
+	}
+}